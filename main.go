@@ -8,6 +8,8 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	// The scratch image has no zoneinfo for TEMPLATE_TIMEZONE
+	_ "time/tzdata"
 
 	"go.mongodb.org/mongo-driver/mongo"
 
@@ -45,5 +47,8 @@ func main() {
 	if err := httpServer.Shutdown(ctx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
+	if err := server.Close(ctx); err != nil {
+		log.Printf("Error closing server: %v", err)
+	}
 	log.Println("Server exited")
 }
@@ -3,26 +3,61 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	BotToken             string
-	ChatID               string
-	DiscordURL           string
-	ProxyURL             string
-	ProxyType            string
-	ProxyUser            string
-	ProxyPass            string
-	DiscordBotToken      string
-	DiscordApplicationID string
-	DiscordPublicKey     string
-	DiscordChannelID     string
-	MongoDBURI           string
-	MongoDBDatabase      string
-	TelegramDisabled     string
+	BotToken               string
+	ChatID                 string
+	DiscordURL             string
+	ProxyURL               string
+	ProxyType              string
+	ProxyUser              string
+	ProxyPass              string
+	DiscordBotToken        string
+	DiscordApplicationID   string
+	DiscordPublicKey       string
+	DiscordChannelID       string
+	DiscordResolveReply    string
+	DiscordThreads         string
+	DiscordGuildID         string
+	DiscordSuppressRoles   []string
+	DiscordSuppressUsers   []string
+	DiscordUnsuppressRoles []string
+	DiscordUnsuppressUsers []string
+	DiscordAckRoles        []string
+	DiscordAckUsers        []string
+	MongoDBURI             string
+	MongoDBDatabase        string
+	TelegramDisabled       string
+	TelegramWebhookSecret  string
+	SlackBotToken          string
+	SlackChannelID         string
+	SlackSigningSecret     string
+	TeamsWebhookURL        string
+	SMTPHost               string
+	SMTPPort               string
+	SMTPUsername           string
+	SMTPPassword           string
+	SMTPFrom               string
+	SMTPTo                 []string
+	SMTPStartTLS           string
+	RoutingConfig          string
+	TemplatesDir           string
+	TemplateTimezone       string
+	ValueFormats           string
+	APIToken               string
+	DeliveryMaxAttempts    string
+	DeliveryInitialBackoff string
+	DeliveryMaxBackoff     string
+	DeliveryPollInterval   string
+	DeliveryWorkers        string
+	DeliveryQueueSize      string
+	DeliveryConcurrency    string
+	HistoryRetention       string
 }
 
 var (
@@ -39,20 +74,85 @@ func GetConfig() (*Config, error) {
 		}
 
 		config = &Config{
-			BotToken:             os.Getenv("BOT_TOKEN"),
-			ChatID:               os.Getenv("CHAT_ID"),
-			DiscordURL:           os.Getenv("DISCORD_URL"),
-			ProxyURL:             os.Getenv("PROXY_URL"),
-			ProxyType:            os.Getenv("PROXY_TYPE"),
-			ProxyUser:            os.Getenv("PROXY_USER"),
-			ProxyPass:            os.Getenv("PROXY_PASS"),
-			DiscordBotToken:      os.Getenv("DISCORD_BOT_TOKEN"),
-			DiscordApplicationID: os.Getenv("DISCORD_APPLICATION_ID"),
-			DiscordPublicKey:     os.Getenv("DISCORD_PUBLIC_KEY"),
-			DiscordChannelID:     os.Getenv("DISCORD_CHANNEL_ID"),
-			MongoDBURI:           os.Getenv("MONGODB_URI"),
-			MongoDBDatabase:      os.Getenv("MONGODB_DATABASE"),
-			TelegramDisabled:     os.Getenv("TELEGRAM_DISABLED"),
+			BotToken:               os.Getenv("BOT_TOKEN"),
+			ChatID:                 os.Getenv("CHAT_ID"),
+			DiscordURL:             os.Getenv("DISCORD_URL"),
+			ProxyURL:               os.Getenv("PROXY_URL"),
+			ProxyType:              os.Getenv("PROXY_TYPE"),
+			ProxyUser:              os.Getenv("PROXY_USER"),
+			ProxyPass:              os.Getenv("PROXY_PASS"),
+			DiscordBotToken:        os.Getenv("DISCORD_BOT_TOKEN"),
+			DiscordApplicationID:   os.Getenv("DISCORD_APPLICATION_ID"),
+			DiscordPublicKey:       os.Getenv("DISCORD_PUBLIC_KEY"),
+			DiscordChannelID:       os.Getenv("DISCORD_CHANNEL_ID"),
+			DiscordResolveReply:    os.Getenv("DISCORD_RESOLVE_REPLY"),
+			DiscordThreads:         os.Getenv("DISCORD_THREADS"),
+			DiscordGuildID:         os.Getenv("DISCORD_GUILD_ID"),
+			DiscordSuppressRoles:   splitList(os.Getenv("DISCORD_SUPPRESS_ROLES")),
+			DiscordSuppressUsers:   splitList(os.Getenv("DISCORD_SUPPRESS_USERS")),
+			DiscordUnsuppressRoles: splitList(os.Getenv("DISCORD_UNSUPPRESS_ROLES")),
+			DiscordUnsuppressUsers: splitList(os.Getenv("DISCORD_UNSUPPRESS_USERS")),
+			DiscordAckRoles:        splitList(os.Getenv("DISCORD_ACK_ROLES")),
+			DiscordAckUsers:        splitList(os.Getenv("DISCORD_ACK_USERS")),
+			MongoDBURI:             os.Getenv("MONGODB_URI"),
+			MongoDBDatabase:        os.Getenv("MONGODB_DATABASE"),
+			TelegramDisabled:       os.Getenv("TELEGRAM_DISABLED"),
+			TelegramWebhookSecret:  os.Getenv("TELEGRAM_WEBHOOK_SECRET"),
+			SlackBotToken:          os.Getenv("SLACK_BOT_TOKEN"),
+			SlackChannelID:         os.Getenv("SLACK_CHANNEL_ID"),
+			SlackSigningSecret:     os.Getenv("SLACK_SIGNING_SECRET"),
+			TeamsWebhookURL:        os.Getenv("TEAMS_WEBHOOK_URL"),
+			SMTPHost:               os.Getenv("SMTP_HOST"),
+			SMTPPort:               os.Getenv("SMTP_PORT"),
+			SMTPUsername:           os.Getenv("SMTP_USERNAME"),
+			SMTPPassword:           os.Getenv("SMTP_PASSWORD"),
+			SMTPFrom:               os.Getenv("SMTP_FROM"),
+			SMTPTo:                 splitList(os.Getenv("SMTP_TO")),
+			SMTPStartTLS:           os.Getenv("SMTP_STARTTLS"),
+			RoutingConfig:          os.Getenv("ROUTING_CONFIG"),
+			TemplatesDir:           os.Getenv("TEMPLATES_DIR"),
+			TemplateTimezone:       os.Getenv("TEMPLATE_TIMEZONE"),
+			ValueFormats:           os.Getenv("VALUE_FORMATS"),
+			APIToken:               os.Getenv("API_TOKEN"),
+			DeliveryMaxAttempts:    os.Getenv("DELIVERY_MAX_ATTEMPTS"),
+			DeliveryInitialBackoff: os.Getenv("DELIVERY_INITIAL_BACKOFF"),
+			DeliveryMaxBackoff:     os.Getenv("DELIVERY_MAX_BACKOFF"),
+			DeliveryPollInterval:   os.Getenv("DELIVERY_POLL_INTERVAL"),
+			DeliveryWorkers:        os.Getenv("DELIVERY_WORKERS"),
+			DeliveryQueueSize:      os.Getenv("DELIVERY_QUEUE_SIZE"),
+			DeliveryConcurrency:    os.Getenv("DELIVERY_CONCURRENCY"),
+			HistoryRetention:       os.Getenv("HISTORY_RETENTION"),
+		}
+
+		if config.SMTPPort == "" {
+			config.SMTPPort = "587"
+		}
+		if config.TemplateTimezone == "" {
+			config.TemplateTimezone = "UTC"
+		}
+		if config.DeliveryMaxAttempts == "" {
+			config.DeliveryMaxAttempts = "5"
+		}
+		if config.DeliveryInitialBackoff == "" {
+			config.DeliveryInitialBackoff = "10s"
+		}
+		if config.DeliveryMaxBackoff == "" {
+			config.DeliveryMaxBackoff = "10m"
+		}
+		if config.DeliveryPollInterval == "" {
+			config.DeliveryPollInterval = "5s"
+		}
+		if config.DeliveryWorkers == "" {
+			config.DeliveryWorkers = "8"
+		}
+		if config.DeliveryQueueSize == "" {
+			config.DeliveryQueueSize = "100"
+		}
+		if config.DeliveryConcurrency == "" {
+			config.DeliveryConcurrency = "2"
+		}
+		if config.HistoryRetention == "" {
+			config.HistoryRetention = "30d"
 		}
 
 		// Validate required fields
@@ -79,6 +179,30 @@ func GetConfig() (*Config, error) {
 			err = fmt.Errorf("DISCORD_PUBLIC_KEY environment variable is required")
 			return
 		}
+		// Slack is optional and enabled by setting a bot token
+		if config.SlackBotToken != "" {
+			if config.SlackChannelID == "" {
+				err = fmt.Errorf("SLACK_CHANNEL_ID environment variable is required")
+				return
+			}
+			if config.SlackSigningSecret == "" {
+				err = fmt.Errorf("SLACK_SIGNING_SECRET environment variable is required")
+				return
+			}
+		}
+
+		// Email is optional and enabled by setting an SMTP host
+		if config.SMTPHost != "" {
+			if config.SMTPFrom == "" {
+				err = fmt.Errorf("SMTP_FROM environment variable is required")
+				return
+			}
+			if len(config.SMTPTo) == 0 {
+				err = fmt.Errorf("SMTP_TO environment variable is required")
+				return
+			}
+		}
+
 		if config.MongoDBURI == "" {
 			err = fmt.Errorf("MONGODB_URI environment variable is required")
 			return
@@ -90,3 +214,14 @@ func GetConfig() (*Config, error) {
 	})
 	return config, err
 }
+
+// splitList parses a comma-separated environment variable, dropping blanks.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
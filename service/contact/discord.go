@@ -4,46 +4,43 @@ import (
 	"fmt"
 
 	"github.com/bwmarrin/discordgo"
-
-	"webhook-server/service/config"
 )
 
 type IDiscordSender interface {
 	SendDiscordMessage(message string) ([]byte, error)
-	SendDiscordMessageWithComponents(message string, components []discordgo.MessageComponent) ([]byte, error)
+	SendDiscordEmbed(embed *discordgo.MessageEmbed, components []discordgo.MessageComponent) ([]byte, error)
 	UpdateMessage(channelID, messageID, content string, components []discordgo.MessageComponent) error
+	UpdateEmbed(channelID, messageID string, embed *discordgo.MessageEmbed, components []discordgo.MessageComponent) error
+	ReplyMessage(channelID, messageID, content string) ([]byte, error)
+	StartThread(channelID, messageID, name string) (string, error)
+	SendThreadMessage(threadID, content string) ([]byte, error)
+	SendThreadEmbed(threadID string, embed *discordgo.MessageEmbed, components []discordgo.MessageComponent) ([]byte, error)
+	ArchiveThread(threadID string) error
 }
 
+// Threads left alone auto-archive after a day
+const discordThreadArchiveMinutes = 1440
+
 type DiscordSender struct {
 	Discord   *discordgo.Session
 	ChannelID string
 }
 
 func (d *DiscordSender) SendDiscordMessage(message string) ([]byte, error) {
-	config, err := config.GetConfig()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get config: %w", err)
-	}
-
-	msg, err := d.Discord.ChannelMessageSend(config.DiscordChannelID, message)
+	msg, err := d.Discord.ChannelMessageSend(d.ChannelID, message)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send Discord message: %w", err)
 	}
 	return []byte(msg.ID), nil
 }
 
-func (d *DiscordSender) SendDiscordMessageWithComponents(message string, components []discordgo.MessageComponent) ([]byte, error) {
-	config, err := config.GetConfig()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get config: %w", err)
-	}
-
-	msg, err := d.Discord.ChannelMessageSendComplex(config.DiscordChannelID, &discordgo.MessageSend{
-		Content:    message,
+func (d *DiscordSender) SendDiscordEmbed(embed *discordgo.MessageEmbed, components []discordgo.MessageComponent) ([]byte, error) {
+	msg, err := d.Discord.ChannelMessageSendComplex(d.ChannelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{embed},
 		Components: components,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to send Discord message with components: %w", err)
+		return nil, fmt.Errorf("failed to send Discord embed: %w", err)
 	}
 	return []byte(msg.ID), nil
 }
@@ -60,3 +57,66 @@ func (d *DiscordSender) UpdateMessage(channelID, messageID, content string, comp
 	}
 	return nil
 }
+
+// UpdateEmbed replaces the embed and components of a message, leaving its
+// content as is.
+func (d *DiscordSender) UpdateEmbed(channelID, messageID string, embed *discordgo.MessageEmbed, components []discordgo.MessageComponent) error {
+	embeds := []*discordgo.MessageEmbed{embed}
+	_, err := d.Discord.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    channelID,
+		ID:         messageID,
+		Embeds:     &embeds,
+		Components: &components,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update Discord embed: %w", err)
+	}
+	return nil
+}
+
+func (d *DiscordSender) ReplyMessage(channelID, messageID, content string) ([]byte, error) {
+	msg, err := d.Discord.ChannelMessageSendReply(channelID, content, &discordgo.MessageReference{
+		MessageID: messageID,
+		ChannelID: channelID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send Discord reply: %w", err)
+	}
+	return []byte(msg.ID), nil
+}
+
+// StartThread opens a thread on a message and returns the thread ID.
+func (d *DiscordSender) StartThread(channelID, messageID, name string) (string, error) {
+	thread, err := d.Discord.MessageThreadStart(channelID, messageID, name, discordThreadArchiveMinutes)
+	if err != nil {
+		return "", fmt.Errorf("failed to start Discord thread: %w", err)
+	}
+	return thread.ID, nil
+}
+
+func (d *DiscordSender) SendThreadMessage(threadID, content string) ([]byte, error) {
+	msg, err := d.Discord.ChannelMessageSend(threadID, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send Discord thread message: %w", err)
+	}
+	return []byte(msg.ID), nil
+}
+
+func (d *DiscordSender) SendThreadEmbed(threadID string, embed *discordgo.MessageEmbed, components []discordgo.MessageComponent) ([]byte, error) {
+	msg, err := d.Discord.ChannelMessageSendComplex(threadID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send Discord thread embed: %w", err)
+	}
+	return []byte(msg.ID), nil
+}
+
+func (d *DiscordSender) ArchiveThread(threadID string) error {
+	archived := true
+	if _, err := d.Discord.ChannelEdit(threadID, &discordgo.ChannelEdit{Archived: &archived}); err != nil {
+		return fmt.Errorf("failed to archive Discord thread: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package contact
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// smtpSession is what the fake SMTP server received.
+type smtpSession struct {
+	from       string
+	recipients []string
+	data       []byte
+	err        error
+}
+
+// fakeSMTPServer accepts one connection, speaks just enough SMTP for
+// net/smtp without STARTTLS or auth, and reports what it received.
+func fakeSMTPServer(t *testing.T) (string, string, <-chan smtpSession) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	sessions := make(chan smtpSession, 1)
+	go func() {
+		var session smtpSession
+		defer func() { sessions <- session }()
+
+		conn, err := listener.Accept()
+		if err != nil {
+			session.err = err
+			return
+		}
+		defer conn.Close()
+
+		text := textproto.NewConn(conn)
+		text.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				session.err = err
+				return
+			}
+			command, arg, _ := strings.Cut(line, " ")
+			switch strings.ToUpper(command) {
+			case "EHLO", "HELO":
+				text.PrintfLine("250 localhost")
+			case "MAIL":
+				session.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+				text.PrintfLine("250 OK")
+			case "RCPT":
+				session.recipients = append(session.recipients, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+				text.PrintfLine("250 OK")
+			case "DATA":
+				text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+				session.data, err = io.ReadAll(text.DotReader())
+				if err != nil {
+					session.err = err
+					return
+				}
+				text.PrintfLine("250 OK")
+			case "QUIT":
+				text.PrintfLine("221 Bye")
+				return
+			default:
+				text.PrintfLine("502 Command not implemented")
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	return host, port, sessions
+}
+
+func TestSendEmail(t *testing.T) {
+	host, port, sessions := fakeSMTPServer(t)
+	sender := &EmailSender{
+		Host: host,
+		Port: port,
+		From: "alerts@example.com",
+		To:   []string{"ops@example.com", "oncall@example.com"},
+	}
+
+	subject := "[FIRING] Ổ đĩa đầy trên db-1"
+	textBody := "Ổ đĩa /data đã dùng 95%\n.\nHết."
+	htmlBody := "<p>Ổ đĩa <b>/data</b> đã dùng 95%</p>"
+	if err := sender.SendEmail(subject, textBody, htmlBody); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+
+	session := <-sessions
+	if session.err != nil {
+		t.Fatalf("fake SMTP server error = %v", session.err)
+	}
+	if session.from != sender.From {
+		t.Errorf("MAIL FROM = %q, want %q", session.from, sender.From)
+	}
+	if !reflect.DeepEqual(session.recipients, sender.To) {
+		t.Errorf("RCPT TO = %v, want %v", session.recipients, sender.To)
+	}
+
+	message, err := mail.ReadMessage(strings.NewReader(string(session.data)))
+	if err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+	if got := message.Header.Get("To"); got != "ops@example.com, oncall@example.com" {
+		t.Errorf("To = %q", got)
+	}
+
+	rawSubject := message.Header.Get("Subject")
+	if !strings.HasPrefix(rawSubject, "=?utf-8?q?") {
+		t.Errorf("Subject = %q, want a Q-encoded word", rawSubject)
+	}
+	decoded, err := new(mime.WordDecoder).DecodeHeader(rawSubject)
+	if err != nil {
+		t.Fatalf("failed to decode subject: %v", err)
+	}
+	if decoded != subject {
+		t.Errorf("decoded Subject = %q, want %q", decoded, subject)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("failed to parse Content-Type: %v", err)
+	}
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, want multipart/alternative", mediaType)
+	}
+
+	parts := multipart.NewReader(message.Body, params["boundary"])
+	want := []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", textBody},
+		{"text/html; charset=utf-8", htmlBody},
+	}
+	for _, w := range want {
+		part, err := parts.NextPart()
+		if err != nil {
+			t.Fatalf("failed to read %s part: %v", w.contentType, err)
+		}
+		if got := part.Header.Get("Content-Type"); got != w.contentType {
+			t.Errorf("part Content-Type = %q, want %q", got, w.contentType)
+		}
+		// multipart.Reader decodes quoted-printable parts
+		content, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("failed to read %s part: %v", w.contentType, err)
+		}
+		if string(content) != w.content {
+			t.Errorf("%s part = %q, want %q", w.contentType, content, w.content)
+		}
+	}
+	if _, err := parts.NextPart(); err != io.EOF {
+		t.Errorf("expected two parts, next part error = %v", err)
+	}
+}
+
+func TestSendEmailTextOnly(t *testing.T) {
+	host, port, sessions := fakeSMTPServer(t)
+	sender := &EmailSender{Host: host, Port: port, From: "alerts@example.com", To: []string{"ops@example.com"}}
+
+	if err := sender.SendEmail("Disk full", "plain body", ""); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+	session := <-sessions
+	if session.err != nil {
+		t.Fatalf("fake SMTP server error = %v", session.err)
+	}
+
+	message, err := mail.ReadMessage(strings.NewReader(string(session.data)))
+	if err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+	_, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("failed to parse Content-Type: %v", err)
+	}
+	parts := multipart.NewReader(message.Body, params["boundary"])
+	part, err := parts.NextPart()
+	if err != nil {
+		t.Fatalf("failed to read part: %v", err)
+	}
+	if got := part.Header.Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("part Content-Type = %q", got)
+	}
+	if _, err := parts.NextPart(); err != io.EOF {
+		t.Errorf("expected a single part, next part error = %v", err)
+	}
+}
+
+func TestSendEmailStartTLSUnsupported(t *testing.T) {
+	host, port, _ := fakeSMTPServer(t)
+	sender := &EmailSender{Host: host, Port: port, From: "alerts@example.com", To: []string{"ops@example.com"}, StartTLS: true}
+
+	err := sender.SendEmail("Disk full", "plain body", "")
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("SendEmail() error = %v, want STARTTLS not supported", err)
+	}
+}
@@ -0,0 +1,133 @@
+package contact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"webhook-server/service/config"
+	"webhook-server/service/model"
+)
+
+type ISlackSender interface {
+	SendSlackMessage(text string, blocks []model.SlackBlock) ([]byte, error)
+	UpdateSlackMessage(channelID, ts, text string, blocks []model.SlackBlock) error
+	RespondSlackEphemeral(responseURL, text string) error
+}
+
+type SlackSender struct {
+	BotToken  string
+	ChannelID string
+}
+
+type slackResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+	TS    string `json:"ts"`
+}
+
+// SendSlackMessage posts a Block Kit message and returns its timestamp, which
+// Slack uses as the message ID.
+func (s *SlackSender) SendSlackMessage(text string, blocks []model.SlackBlock) ([]byte, error) {
+	resp, err := s.call("chat.postMessage", model.SlackMessage{
+		Channel: s.ChannelID,
+		Text:    text,
+		Blocks:  blocks,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []byte(resp.TS), nil
+}
+
+func (s *SlackSender) UpdateSlackMessage(channelID, ts, text string, blocks []model.SlackBlock) error {
+	_, err := s.call("chat.update", model.SlackMessage{
+		Channel: channelID,
+		TS:      ts,
+		Text:    text,
+		Blocks:  blocks,
+	})
+	return err
+}
+
+// RespondSlackEphemeral answers an interaction with a message only the user
+// who clicked sees, leaving the original message as it is.
+func (s *SlackSender) RespondSlackEphemeral(responseURL, text string) error {
+	config, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get config: %w", err)
+	}
+
+	body := new(bytes.Buffer)
+	if err := json.NewEncoder(body).Encode(model.SlackResponse{ResponseType: "ephemeral", Text: text}); err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+
+	client, err := newHTTPClient(config)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Post(responseURL, "application/json; charset=utf-8", body)
+	if err != nil {
+		return fmt.Errorf("failed to send HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		text, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("slack response_url returned %s: %s", resp.Status, text)
+	}
+	return nil
+}
+
+func (s *SlackSender) call(method string, message model.SlackMessage) (*slackResponse, error) {
+	config, err := config.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+
+	body := new(bytes.Buffer)
+	if err := json.NewEncoder(body).Encode(message); err != nil {
+		return nil, fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	client, err := newHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", "https://slack.com/api/"+method, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+s.BotToken)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	text, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("slack API returned %s: %s", resp.Status, text)
+	}
+
+	var result slackResponse
+	if err := json.Unmarshal(text, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode slack response: %w", err)
+	}
+	if !result.OK {
+		return nil, fmt.Errorf("slack API %s failed: %s", method, result.Error)
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,69 @@
+package contact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"webhook-server/service/config"
+	"webhook-server/service/model"
+)
+
+type ITeamsSender interface {
+	SendTeamsCard(card model.AdaptiveCard) ([]byte, error)
+}
+
+type TeamsSender struct {
+	WebhookURL string
+}
+
+func (t *TeamsSender) SendTeamsCard(card model.AdaptiveCard) ([]byte, error) {
+	config, err := config.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+
+	body := new(bytes.Buffer)
+	if err := json.NewEncoder(body).Encode(model.TeamsMessage{
+		Type: "message",
+		Attachments: []model.TeamsAttachment{
+			{
+				ContentType: "application/vnd.microsoft.card.adaptive",
+				Content:     card,
+			},
+		},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	client, err := newHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", t.WebhookURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	text, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	// Incoming webhooks answer 200, Workflows triggers answer 202
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return text, fmt.Errorf("teams webhook returned %s: %s", resp.Status, text)
+	}
+
+	return text, nil
+}
@@ -5,7 +5,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"html/template"
 	"io"
 	"net"
 	"net/http"
@@ -15,17 +14,26 @@ import (
 	"golang.org/x/net/proxy"
 
 	"webhook-server/service/config"
-	"webhook-server/service/helper"
 	"webhook-server/service/model"
 )
 
 type ITelegramSender interface {
 	SendTelegramMessage(message string) ([]byte, error)
+	SendTelegramMessageWithKeyboard(message string, keyboard *model.InlineKeyboardMarkup) ([]byte, error)
+	EditTelegramMessage(chatID string, messageID int, message string, keyboard *model.InlineKeyboardMarkup) error
+	AnswerCallbackQuery(callbackQueryID, text string) error
 }
 
-type TelegramSender struct{}
+// TelegramSender posts to ChatID, or to CHAT_ID when it is empty.
+type TelegramSender struct {
+	ChatID string
+}
 
 func (t *TelegramSender) SendTelegramMessage(message string) ([]byte, error) {
+	return t.SendTelegramMessageWithKeyboard(message, nil)
+}
+
+func (t *TelegramSender) SendTelegramMessageWithKeyboard(message string, keyboard *model.InlineKeyboardMarkup) ([]byte, error) {
 	config, err := config.GetConfig()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get config: %w", err)
@@ -36,27 +44,61 @@ func (t *TelegramSender) SendTelegramMessage(message string) ([]byte, error) {
 		return data, nil
 	}
 
-	telegramURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", config.BotToken)
+	chatID := t.ChatID
+	if chatID == "" {
+		chatID = config.ChatID
+	}
+
+	return t.call(config, "sendMessage", model.TelegramMessage{
+		ChatID:      chatID,
+		Text:        message,
+		ParseMode:   "HTML",
+		ReplyMarkup: keyboard,
+	})
+}
 
-	body := new(bytes.Buffer)
-	if err := json.NewEncoder(body).Encode(model.TelegramMessage{
-		ChatID:    config.ChatID,
-		Text:      message,
-		ParseMode: "HTML",
-	}); err != nil {
-		return nil, fmt.Errorf("failed to encode message: %w", err)
+// EditTelegramMessage replaces the text of a sent message. A nil keyboard
+// removes the inline buttons.
+func (t *TelegramSender) EditTelegramMessage(chatID string, messageID int, message string, keyboard *model.InlineKeyboardMarkup) error {
+	config, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get config: %w", err)
 	}
 
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+	_, err = t.call(config, "editMessageText", model.TelegramMessage{
+		ChatID:      chatID,
+		MessageID:   messageID,
+		Text:        message,
+		ParseMode:   "HTML",
+		ReplyMarkup: keyboard,
+	})
+	return err
+}
+
+func (t *TelegramSender) AnswerCallbackQuery(callbackQueryID, text string) error {
+	config, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get config: %w", err)
 	}
 
-	if config.ProxyURL != "" {
-		transport, err := createProxyTransport(config)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create proxy transport: %w", err)
-		}
-		client.Transport = transport
+	_, err = t.call(config, "answerCallbackQuery", map[string]string{
+		"callback_query_id": callbackQueryID,
+		"text":              text,
+	})
+	return err
+}
+
+func (t *TelegramSender) call(config *config.Config, method string, payload interface{}) ([]byte, error) {
+	telegramURL := fmt.Sprintf("https://api.telegram.org/bot%s/%s", config.BotToken, method)
+
+	body := new(bytes.Buffer)
+	if err := json.NewEncoder(body).Encode(payload); err != nil {
+		return nil, fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	client, err := newHTTPClient(config)
+	if err != nil {
+		return nil, err
 	}
 
 	req, err := http.NewRequest("POST", telegramURL, body)
@@ -83,6 +125,24 @@ func (t *TelegramSender) SendTelegramMessage(message string) ([]byte, error) {
 	return text, nil
 }
 
+// newHTTPClient returns the client used for outgoing chat API calls, routed
+// through the configured proxy if any.
+func newHTTPClient(config *config.Config) (*http.Client, error) {
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	if config.ProxyURL != "" {
+		transport, err := createProxyTransport(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create proxy transport: %w", err)
+		}
+		client.Transport = transport
+	}
+
+	return client, nil
+}
+
 func createProxyTransport(config *config.Config) (*http.Transport, error) {
 	proxyURL, err := url.Parse(config.ProxyURL)
 	if err != nil {
@@ -118,63 +178,3 @@ func createProxyTransport(config *config.Config) (*http.Transport, error) {
 		Proxy: http.ProxyURL(proxyURL),
 	}, nil
 }
-
-const telegramTemplate = `
-{{- define "telegram_harddrive" -}}
-{{- range . -}}
-{{- if eq .Status "firing" }}{{ template "telegram_alert_firing" . }}{{ end -}}
-{{- if eq .Status "resolved" }}{{ template "telegram_alert_resolved" . }}{{ end -}}
-{{- end -}}
-{{- end -}}
-
-{{- define "telegram_alert_firing" -}}
-❗️❗️❗️❗️❗️ CẢNH BÁO ❗️❗️❗️❗️❗️
-
-🚨 Vấn đề: {{ .Annotations.summary }} 🚨
-<b>Thời gian hoạt động:</b> {{ printf "%.2f" (div .Values.B 31536000) }} năm
-
-<b>Thông tin node:</b>
-{{- if index .Labels "instance" }}
-- Node: {{ index .Labels "instance" }}
-{{- end }}
-{{- if index .Labels "device" }}
-- Device: {{ index .Labels "device" }}
-{{- end }}
-
-━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
-{{- end -}}
-
-{{- define "telegram_alert_resolved" -}}
-🤟🤟🤟 Đã giải quyết xong 🤘🤘🤘
-
-🔧🛠️✨ Vấn đề: {{ .Annotations.summary }} 🔩⚙️🔨
-
-<b>Thông tin node:</b>
-{{- if index .Labels "instance" }}
-- Node: {{ index .Labels "instance" }}
-{{- end }}
-{{- if index .Labels "device" }}
-- Device: {{ index .Labels "device" }}
-{{- end }}
-
-━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
-{{- end -}}
-`
-
-func RenderTelegramMessage(alerts []model.Alert) (string, error) {
-	funcMap := template.FuncMap{
-		"div": helper.SafeDivide,
-	}
-
-	tmpl, err := template.New("telegram").Funcs(funcMap).Parse(telegramTemplate)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse template: %w", err)
-	}
-
-	var buf bytes.Buffer
-	if err := tmpl.ExecuteTemplate(&buf, "telegram_harddrive", alerts); err != nil {
-		return "", fmt.Errorf("failed to execute template: %w", err)
-	}
-
-	return buf.String(), nil
-}
@@ -0,0 +1,53 @@
+package helper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHumanizeDuration(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{in: 0, want: "0s"},
+		{in: 30 * time.Second, want: "30s"},
+		{in: 90 * time.Minute, want: "1h 30m"},
+		{in: 24 * time.Hour, want: "1d"},
+		{in: 26*time.Hour + 5*time.Minute + 10*time.Second, want: "1d 2h 5m"},
+	}
+	for _, tt := range tests {
+		if got := HumanizeDuration(tt.in); got != tt.want {
+			t.Errorf("HumanizeDuration(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "1d12h", want: 36 * time.Hour},
+		{in: "1w", want: 7 * 24 * time.Hour},
+		{in: "1w2d", want: 9 * 24 * time.Hour},
+		{in: "3d", want: 72 * time.Hour},
+		{in: "90m", want: 90 * time.Minute},
+		{in: " 2h ", want: 2 * time.Hour},
+		{in: "1h1d", wantErr: true},
+		{in: "d", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDuration(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"math"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TemplateFuncs returns the functions available in message templates. Times
+// are formatted in loc.
+func TemplateFuncs(loc *time.Location) map[string]interface{} {
+	return map[string]interface{}{
+		"div":                SafeDivide,
+		"since":              Since,
+		"humanizeDuration":   humanizeDurationValue,
+		"humanize":           HumanizeSI,
+		"humanizeBytes":      HumanizeBytes,
+		"humanizePercentage": HumanizePercentage,
+		"date": func(layout string, t time.Time) string {
+			return FormatTime(t, layout, loc)
+		},
+		"toUpper":        strings.ToUpper,
+		"toLower":        strings.ToLower,
+		"trim":           strings.TrimSpace,
+		"join":           Join,
+		"split":          func(sep, s string) []string { return strings.Split(s, sep) },
+		"reReplaceAll":   ReReplaceAll,
+		"sortedLabels":   SortedLabels,
+		"onlyLabels":     OnlyLabels,
+		"excludeLabels":  ExcludeLabels,
+		"safeHTML":       func(s string) htmltemplate.HTML { return htmltemplate.HTML(s) },
+		"escapeMarkdown": EscapeMarkdown,
+		"escapeSlack":    EscapeSlack,
+		"queryEscape":    url.QueryEscape,
+		"pathEscape":     url.PathEscape,
+		"buildURL":       BuildURL,
+	}
+}
+
+// Since returns the time elapsed since t, or zero when t is not set.
+func Since(t time.Time) time.Duration {
+	if t.IsZero() {
+		return 0
+	}
+	return time.Since(t)
+}
+
+// humanizeDurationValue formats a duration, or a number of seconds.
+func humanizeDurationValue(v interface{}) (string, error) {
+	if d, ok := v.(time.Duration); ok {
+		return HumanizeDuration(d), nil
+	}
+	seconds, err := toFloat64(v)
+	if err != nil {
+		return "", err
+	}
+	return HumanizeDuration(time.Duration(seconds * float64(time.Second))), nil
+}
+
+var (
+	siPrefixes      = []string{"", "k", "M", "G", "T", "P", "E", "Z", "Y"}
+	siSmallPrefixes = []string{"", "m", "µ", "n", "p", "f", "a", "z", "y"}
+	binaryUnits     = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+)
+
+// HumanizeSI formats a number with an SI prefix, e.g. 1234567 as "1.235M"
+// and 0.0025 as "2.5m".
+func HumanizeSI(v interface{}) (string, error) {
+	f, err := toFloat64(v)
+	if err != nil {
+		return "", err
+	}
+	if f == 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Sprintf("%.4g", f), nil
+	}
+
+	i := 0
+	if math.Abs(f) >= 1 {
+		for math.Abs(f) >= 1000 && i < len(siPrefixes)-1 {
+			f /= 1000
+			i++
+		}
+		return fmt.Sprintf("%.4g%s", f, siPrefixes[i]), nil
+	}
+	for math.Abs(f) < 1 && i < len(siSmallPrefixes)-1 {
+		f *= 1000
+		i++
+	}
+	return fmt.Sprintf("%.4g%s", f, siSmallPrefixes[i]), nil
+}
+
+// HumanizeBytes formats a number of bytes with binary units, e.g. 1610612736
+// as "1.5 GiB".
+func HumanizeBytes(v interface{}) (string, error) {
+	f, err := toFloat64(v)
+	if err != nil {
+		return "", err
+	}
+
+	i := 0
+	for math.Abs(f) >= 1024 && i < len(binaryUnits)-1 {
+		f /= 1024
+		i++
+	}
+	return fmt.Sprintf("%.4g %s", f, binaryUnits[i]), nil
+}
+
+// HumanizePercentage formats a ratio as a percentage, e.g. 0.4257 as
+// "42.57%".
+func HumanizePercentage(v interface{}) (string, error) {
+	f, err := toFloat64(v)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%.4g%%", f*100), nil
+}
+
+// FormatTime formats t in loc with a Go layout such as "2006-01-02 15:04".
+// A zero time is formatted as an empty string.
+func FormatTime(t time.Time, layout string, loc *time.Location) string {
+	if t.IsZero() {
+		return ""
+	}
+	if loc != nil {
+		t = t.In(loc)
+	}
+	return t.Format(layout)
+}
+
+// Join joins the elements of a slice with sep.
+func Join(sep string, v interface{}) (string, error) {
+	if s, ok := v.([]string); ok {
+		return strings.Join(s, sep), nil
+	}
+
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return "", fmt.Errorf("cannot join %T", v)
+	}
+	parts := make([]string, value.Len())
+	for i := range parts {
+		parts[i] = fmt.Sprint(value.Index(i).Interface())
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// ReReplaceAll replaces the matches of pattern in text, expanding $1 style
+// references in replacement.
+func ReReplaceAll(pattern, replacement, text string) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return re.ReplaceAllString(text, replacement), nil
+}
+
+// Label is a label name and value, as returned by SortedLabels.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// SortedLabels returns the labels sorted by name.
+func SortedLabels(labels map[string]string) []Label {
+	sorted := make([]Label, 0, len(labels))
+	for name, value := range labels {
+		sorted = append(sorted, Label{Name: name, Value: value})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// OnlyLabels returns the given labels, dropping all others.
+func OnlyLabels(labels map[string]string, names ...string) map[string]string {
+	filtered := make(map[string]string)
+	for _, name := range names {
+		if value, ok := labels[name]; ok {
+			filtered[name] = value
+		}
+	}
+	return filtered
+}
+
+// ExcludeLabels returns the labels without the given ones.
+func ExcludeLabels(labels map[string]string, names ...string) map[string]string {
+	filtered := make(map[string]string, len(labels))
+	for name, value := range labels {
+		filtered[name] = value
+	}
+	for _, name := range names {
+		delete(filtered, name)
+	}
+	return filtered
+}
+
+var markdownReplacer = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`",
+	"|", `\|`, ">", `\>`, "#", `\#`, "[", `\[`, "]", `\]`,
+	"(", `\(`, ")", `\)`,
+)
+
+// EscapeMarkdown escapes Markdown formatting characters for Discord and
+// Teams.
+func EscapeMarkdown(s string) string {
+	return markdownReplacer.Replace(s)
+}
+
+var slackReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// EscapeSlack escapes the control characters of Slack mrkdwn.
+func EscapeSlack(s string) string {
+	return slackReplacer.Replace(s)
+}
+
+// BuildURL adds query parameters, given as name and value pairs, to base.
+func BuildURL(base string, pairs ...string) (string, error) {
+	if len(pairs)%2 != 0 {
+		return "", fmt.Errorf("buildURL needs name and value pairs")
+	}
+
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", base, err)
+	}
+	query := u.Query()
+	for i := 0; i < len(pairs); i += 2 {
+		query.Add(pairs[i], pairs[i+1])
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+func toFloat64(v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q", v)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("cannot convert %T to a number", v)
+}
@@ -0,0 +1,301 @@
+package helper
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSince(t *testing.T) {
+	if got := Since(time.Time{}); got != 0 {
+		t.Errorf("Since(zero) = %v, want 0", got)
+	}
+	if got := Since(time.Now().Add(-time.Hour)); got < time.Hour {
+		t.Errorf("Since(1h ago) = %v, want at least 1h", got)
+	}
+}
+
+func TestHumanizeDurationValue(t *testing.T) {
+	tests := []struct {
+		in      interface{}
+		want    string
+		wantErr bool
+	}{
+		{in: 90 * time.Minute, want: "1h 30m"},
+		{in: 3600, want: "1h"},
+		{in: 45.0, want: "45s"},
+		{in: "90", want: "1m"},
+		{in: "abc", wantErr: true},
+		{in: []int{1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := humanizeDurationValue(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("humanizeDurationValue(%v) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("humanizeDurationValue(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHumanizeSI(t *testing.T) {
+	tests := []struct {
+		in      interface{}
+		want    string
+		wantErr bool
+	}{
+		{in: 1234567, want: "1.235M"},
+		{in: 1500.0, want: "1.5k"},
+		{in: -1500, want: "-1.5k"},
+		{in: 1e27, want: "1000Y"},
+		{in: 1, want: "1"},
+		{in: 0.0025, want: "2.5m"},
+		{in: 0.000001, want: "1µ"},
+		{in: 0, want: "0"},
+		{in: math.NaN(), want: "NaN"},
+		{in: math.Inf(1), want: "+Inf"},
+		{in: "2048", want: "2.048k"},
+		{in: "abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := HumanizeSI(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("HumanizeSI(%v) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("HumanizeSI(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHumanizeBytes(t *testing.T) {
+	tests := []struct {
+		in      interface{}
+		want    string
+		wantErr bool
+	}{
+		{in: 512, want: "512 B"},
+		{in: 1024, want: "1 KiB"},
+		{in: 1610612736, want: "1.5 GiB"},
+		{in: int64(1) << 62, want: "4 EiB"},
+		{in: "abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := HumanizeBytes(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("HumanizeBytes(%v) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("HumanizeBytes(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHumanizePercentage(t *testing.T) {
+	tests := []struct {
+		in      interface{}
+		want    string
+		wantErr bool
+	}{
+		{in: 0.4257, want: "42.57%"},
+		{in: "0.5", want: "50%"},
+		{in: 1, want: "100%"},
+		{in: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := HumanizePercentage(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("HumanizePercentage(%v) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("HumanizePercentage(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		t    time.Time
+		loc  *time.Location
+		want string
+	}{
+		{name: "utc", t: ts, want: "2024-01-02 03:04"},
+		{name: "timezone", t: ts, loc: time.FixedZone("ICT", 7*60*60), want: "2024-01-02 10:04"},
+		{name: "zero", t: time.Time{}, loc: time.UTC, want: ""},
+	}
+	for _, tt := range tests {
+		if got := FormatTime(tt.t, "2006-01-02 15:04", tt.loc); got != tt.want {
+			t.Errorf("FormatTime(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestJoin(t *testing.T) {
+	tests := []struct {
+		in      interface{}
+		want    string
+		wantErr bool
+	}{
+		{in: []string{"a", "b"}, want: "a, b"},
+		{in: []int{1, 2, 3}, want: "1, 2, 3"},
+		{in: [2]float64{1.5, 2}, want: "1.5, 2"},
+		{in: []interface{}{}, want: ""},
+		{in: "ab", wantErr: true},
+		{in: 42, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Join(", ", tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Join(%v) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Join(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestReReplaceAll(t *testing.T) {
+	tests := []struct {
+		pattern     string
+		replacement string
+		text        string
+		want        string
+		wantErr     bool
+	}{
+		{pattern: `(\w+)\.example\.com:\d+`, replacement: "$1", text: "db-1.example.com:9100", want: "db-1"},
+		{pattern: `\s+`, replacement: " ", text: "a  b\tc", want: "a b c"},
+		{pattern: `x`, replacement: "y", text: "abc", want: "abc"},
+		{pattern: `(`, text: "abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ReReplaceAll(tt.pattern, tt.replacement, tt.text)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ReReplaceAll(%q) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ReReplaceAll(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestSortedLabels(t *testing.T) {
+	labels := map[string]string{"job": "node", "alertname": "DiskFull", "instance": "db-1"}
+	want := []Label{
+		{Name: "alertname", Value: "DiskFull"},
+		{Name: "instance", Value: "db-1"},
+		{Name: "job", Value: "node"},
+	}
+	if got := SortedLabels(labels); !reflect.DeepEqual(got, want) {
+		t.Errorf("SortedLabels() = %v, want %v", got, want)
+	}
+	if got := SortedLabels(nil); len(got) != 0 {
+		t.Errorf("SortedLabels(nil) = %v, want empty", got)
+	}
+}
+
+func TestOnlyAndExcludeLabels(t *testing.T) {
+	labels := map[string]string{"alertname": "DiskFull", "instance": "db-1", "job": "node"}
+	tests := []struct {
+		name string
+		got  map[string]string
+		want map[string]string
+	}{
+		{
+			name: "only",
+			got:  OnlyLabels(labels, "instance", "missing"),
+			want: map[string]string{"instance": "db-1"},
+		},
+		{
+			name: "only none",
+			got:  OnlyLabels(labels),
+			want: map[string]string{},
+		},
+		{
+			name: "exclude",
+			got:  ExcludeLabels(labels, "job", "missing"),
+			want: map[string]string{"alertname": "DiskFull", "instance": "db-1"},
+		},
+		{
+			name: "exclude none",
+			got:  ExcludeLabels(labels),
+			want: labels,
+		},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+	if len(labels) != 3 {
+		t.Errorf("ExcludeLabels modified its input: %v", labels)
+	}
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "plain text", want: "plain text"},
+		{in: "*bold* _it_ ~x~", want: `\*bold\* \_it\_ \~x\~`},
+		{in: "[link](url)", want: `\[link\]\(url\)`},
+		{in: "`code` > # |", want: "\\`code\\` \\> \\# \\|"},
+		{in: `C:\path`, want: `C:\\path`},
+	}
+	for _, tt := range tests {
+		if got := EscapeMarkdown(tt.in); got != tt.want {
+			t.Errorf("EscapeMarkdown(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEscapeSlack(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "plain *text*", want: "plain *text*"},
+		{in: "<a & b>", want: "&lt;a &amp; b&gt;"},
+		{in: "&amp;", want: "&amp;amp;"},
+	}
+	for _, tt := range tests {
+		if got := EscapeSlack(tt.in); got != tt.want {
+			t.Errorf("EscapeSlack(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBuildURL(t *testing.T) {
+	tests := []struct {
+		base    string
+		pairs   []string
+		want    string
+		wantErr bool
+	}{
+		{base: "https://grafana.example.com/d/abc", want: "https://grafana.example.com/d/abc"},
+		{base: "https://grafana.example.com/d/abc", pairs: []string{"var-instance", "db 1"}, want: "https://grafana.example.com/d/abc?var-instance=db+1"},
+		{base: "https://grafana.example.com/d/abc?orgId=1", pairs: []string{"from", "now-1h"}, want: "https://grafana.example.com/d/abc?from=now-1h&orgId=1"},
+		{base: "https://grafana.example.com", pairs: []string{"orgId"}, wantErr: true},
+		{base: "://bad", pairs: []string{"a", "b"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := BuildURL(tt.base, tt.pairs...)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("BuildURL(%q, %v) error = %v, wantErr %v", tt.base, tt.pairs, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("BuildURL(%q, %v) = %q, want %q", tt.base, tt.pairs, got, tt.want)
+		}
+	}
+}
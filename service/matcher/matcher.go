@@ -0,0 +1,147 @@
+package matcher
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Matcher matches a single label, Alertmanager style. Regular expressions
+// are fully anchored and a missing label matches as an empty string.
+type Matcher struct {
+	Name    string `json:"name" bson:"name"`
+	Value   string `json:"value" bson:"value"`
+	IsRegex bool   `json:"isRegex" bson:"is_regex"`
+	IsEqual bool   `json:"isEqual" bson:"is_equal"`
+
+	re *regexp.Regexp
+}
+
+type Matchers []*Matcher
+
+// labelName is the Prometheus label name syntax.
+var labelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+func New(name, value string, isRegex, isEqual bool) (*Matcher, error) {
+	if !labelName.MatchString(name) {
+		return nil, fmt.Errorf("invalid label name %q", name)
+	}
+	m := &Matcher{Name: name, Value: value, IsRegex: isRegex, IsEqual: isEqual}
+	if err := m.Compile(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Parse parses a matcher such as `severity="critical"`, `job!=node`,
+// `instance=~"db-.*"` or `device!~loop.*`. Quotes around the value are
+// optional.
+func Parse(s string) (*Matcher, error) {
+	s = strings.TrimSpace(s)
+
+	i := strings.IndexAny(s, "=!")
+	if i <= 0 {
+		return nil, fmt.Errorf("invalid matcher %q", s)
+	}
+	name := strings.TrimSpace(s[:i])
+
+	var op string
+	for _, candidate := range []string{"=~", "!~", "!=", "="} {
+		if strings.HasPrefix(s[i:], candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		return nil, fmt.Errorf("invalid matcher %q", s)
+	}
+
+	value := strings.TrimSpace(s[i+len(op):])
+	if strings.HasPrefix(value, `"`) {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid matcher value in %q: %w", s, err)
+		}
+		value = unquoted
+	}
+
+	return New(name, value, op == "=~" || op == "!~", op == "=" || op == "=~")
+}
+
+func ParseAll(items []string) (Matchers, error) {
+	matchers := make(Matchers, 0, len(items))
+	for _, item := range items {
+		m, err := Parse(item)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers, nil
+}
+
+// Compile prepares the regular expression. It must be called on matchers
+// decoded from JSON or BSON before they are used concurrently.
+func (m *Matcher) Compile() error {
+	if !m.IsRegex {
+		return nil
+	}
+	re, err := regexp.Compile("^(?:" + m.Value + ")$")
+	if err != nil {
+		return fmt.Errorf("invalid regular expression %q: %w", m.Value, err)
+	}
+	m.re = re
+	return nil
+}
+
+func (m *Matcher) Matches(labels map[string]string) bool {
+	value := labels[m.Name]
+
+	var matched bool
+	if m.IsRegex {
+		re := m.re
+		if re == nil {
+			var err error
+			if re, err = regexp.Compile("^(?:" + m.Value + ")$"); err != nil {
+				return false
+			}
+		}
+		matched = re.MatchString(value)
+	} else {
+		matched = value == m.Value
+	}
+
+	return matched == m.IsEqual
+}
+
+func (m *Matcher) String() string {
+	op := "="
+	switch {
+	case m.IsRegex && m.IsEqual:
+		op = "=~"
+	case m.IsRegex:
+		op = "!~"
+	case !m.IsEqual:
+		op = "!="
+	}
+	return m.Name + op + strconv.Quote(m.Value)
+}
+
+// Matches reports whether all matchers match the labels.
+func (ms Matchers) Matches(labels map[string]string) bool {
+	for _, m := range ms {
+		if !m.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func (ms Matchers) String() string {
+	items := make([]string, len(ms))
+	for i, m := range ms {
+		items[i] = m.String()
+	}
+	return "{" + strings.Join(items, ", ") + "}"
+}
@@ -0,0 +1,167 @@
+package matcher
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Matcher
+		wantErr bool
+	}{
+		{in: `severity="critical"`, want: Matcher{Name: "severity", Value: "critical", IsEqual: true}},
+		{in: `severity=critical`, want: Matcher{Name: "severity", Value: "critical", IsEqual: true}},
+		{in: ` job != node `, want: Matcher{Name: "job", Value: "node"}},
+		{in: `instance=~"db-.*"`, want: Matcher{Name: "instance", Value: "db-.*", IsRegex: true, IsEqual: true}},
+		{in: `device!~loop.*`, want: Matcher{Name: "device", Value: "loop.*", IsRegex: true}},
+		{in: `summary="a \"quoted\" value"`, want: Matcher{Name: "summary", Value: `a "quoted" value`, IsEqual: true}},
+		{in: `path="C:\\data"`, want: Matcher{Name: "path", Value: `C:\data`, IsEqual: true}},
+		{in: `msg="a=b, c!=d"`, want: Matcher{Name: "msg", Value: "a=b, c!=d", IsEqual: true}},
+		{in: `empty=""`, want: Matcher{Name: "empty", Value: "", IsEqual: true}},
+		{in: `_private="x"`, want: Matcher{Name: "_private", Value: "x", IsEqual: true}},
+		{in: `severity`, wantErr: true},
+		{in: `="critical"`, wantErr: true},
+		{in: `severity!critical`, wantErr: true},
+		{in: `severity="critical`, wantErr: true},
+		{in: `instance=~"db-("`, wantErr: true},
+		{in: `1abc="x"`, wantErr: true},
+		{in: `my-label="x"`, wantErr: true},
+		{in: `my label="x"`, wantErr: true},
+		{in: `label.name="x"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got.Name != tt.want.Name || got.Value != tt.want.Value || got.IsRegex != tt.want.IsRegex || got.IsEqual != tt.want.IsEqual {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.in, *got, tt.want)
+		}
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	got, err := ParseAll([]string{`alertname="DiskFull"`, `instance=~"db-.*"`})
+	if err != nil {
+		t.Fatalf("ParseAll() error = %v", err)
+	}
+	if len(got) != 2 || got[0].Name != "alertname" || got[1].Name != "instance" {
+		t.Errorf("ParseAll() = %v", got)
+	}
+
+	if got, err := ParseAll(nil); err != nil || len(got) != 0 {
+		t.Errorf("ParseAll(nil) = %v, %v, want empty", got, err)
+	}
+	if _, err := ParseAll([]string{`alertname="DiskFull"`, `bad`}); err == nil {
+		t.Errorf("ParseAll() with an invalid matcher returned no error")
+	}
+}
+
+func TestMatches(t *testing.T) {
+	labels := map[string]string{"alertname": "DiskFull", "instance": "db-1:9100", "severity": "critical"}
+	tests := []struct {
+		matcher string
+		want    bool
+	}{
+		{matcher: `alertname="DiskFull"`, want: true},
+		{matcher: `alertname="Disk"`, want: false},
+		{matcher: `alertname!="DiskFull"`, want: false},
+		{matcher: `alertname!="CPUHigh"`, want: true},
+
+		// Regular expressions are anchored at both ends
+		{matcher: `instance=~"db-.*"`, want: true},
+		{matcher: `instance=~"db"`, want: false},
+		{matcher: `instance=~"1:9100"`, want: false},
+		{matcher: `instance=~".*:9100"`, want: true},
+		{matcher: `severity=~"critical|warning"`, want: true},
+		{matcher: `instance!~"db-.*"`, want: false},
+		{matcher: `instance!~"web-.*"`, want: true},
+
+		// A missing label matches as an empty string
+		{matcher: `device=""`, want: true},
+		{matcher: `device="sda"`, want: false},
+		{matcher: `device!="sda"`, want: true},
+		{matcher: `device!=""`, want: false},
+		{matcher: `device=~".*"`, want: true},
+		{matcher: `device=~".+"`, want: false},
+		{matcher: `device!~"sd.*"`, want: true},
+		{matcher: `device!~".*"`, want: false},
+	}
+	for _, tt := range tests {
+		m, err := Parse(tt.matcher)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.matcher, err)
+		}
+		if got := m.Matches(labels); got != tt.want {
+			t.Errorf("%s matches %v = %v, want %v", tt.matcher, labels, got, tt.want)
+		}
+	}
+}
+
+func TestMatchesDecoded(t *testing.T) {
+	// Matchers decoded from JSON or BSON have no compiled expression
+	m := &Matcher{Name: "instance", Value: "db-.*", IsRegex: true, IsEqual: true}
+	if !m.Matches(map[string]string{"instance": "db-1"}) {
+		t.Errorf("uncompiled %s does not match db-1", m)
+	}
+	invalid := &Matcher{Name: "instance", Value: "db-(", IsRegex: true, IsEqual: true}
+	if invalid.Matches(map[string]string{"instance": "db-("}) {
+		t.Errorf("invalid expression %s matched", invalid)
+	}
+}
+
+func TestMatchersMatches(t *testing.T) {
+	labels := map[string]string{"alertname": "DiskFull", "instance": "db-1"}
+	tests := []struct {
+		matchers []string
+		want     bool
+	}{
+		{matchers: nil, want: true},
+		{matchers: []string{`alertname="DiskFull"`, `instance=~"db-.*"`}, want: true},
+		{matchers: []string{`alertname="DiskFull"`, `instance=~"web-.*"`}, want: false},
+	}
+	for _, tt := range tests {
+		matchers, err := ParseAll(tt.matchers)
+		if err != nil {
+			t.Fatalf("ParseAll(%v) error = %v", tt.matchers, err)
+		}
+		if got := matchers.Matches(labels); got != tt.want {
+			t.Errorf("%v matches = %v, want %v", matchers, got, tt.want)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: `severity=critical`, want: `severity="critical"`},
+		{in: `job!=node`, want: `job!="node"`},
+		{in: `instance=~db-.*`, want: `instance=~"db-.*"`},
+		{in: `device!~loop.*`, want: `device!~"loop.*"`},
+		{in: `summary="a \"b\""`, want: `summary="a \"b\""`},
+	}
+	for _, tt := range tests {
+		m, err := Parse(tt.in)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.in, err)
+		}
+		if got := m.String(); got != tt.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+		// The string form parses back to the same matcher
+		again, err := Parse(m.String())
+		if err != nil || again.String() != m.String() || again.IsRegex != m.IsRegex || again.IsEqual != m.IsEqual {
+			t.Errorf("Parse(%q) = %v, %v, want %v", m.String(), again, err, m)
+		}
+	}
+
+	matchers, _ := ParseAll([]string{`a="1"`, `b!~"2"`})
+	if got := matchers.String(); got != `{a="1", b!~"2"}` {
+		t.Errorf("Matchers.String() = %q", got)
+	}
+}
@@ -2,8 +2,42 @@ package model
 
 import "time"
 
-type GrafanaAlert struct {
-	Alerts []Alert `json:"alerts"`
+const (
+	SourceGrafana      = "grafana"
+	SourceAlertmanager = "alertmanager"
+)
+
+// WebhookMessage is the payload posted by both Grafana unified alerting and
+// the Prometheus Alertmanager webhook receiver (version 4).
+type WebhookMessage struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	TruncatedAlerts   int               `json:"truncatedAlerts"`
+	Status            string            `json:"status"`
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []Alert           `json:"alerts"`
+
+	// Grafana only
+	OrgID   int64  `json:"orgId,omitempty"`
+	Title   string `json:"title,omitempty"`
+	State   string `json:"state,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Source reports whether the payload was sent by Grafana or Alertmanager.
+// Grafana always sets orgId/state/title; Alertmanager sends version "4".
+func (m *WebhookMessage) Source() string {
+	if m.OrgID != 0 || m.State != "" || m.Title != "" {
+		return SourceGrafana
+	}
+	if m.Version == "4" {
+		return SourceAlertmanager
+	}
+	return SourceGrafana
 }
 
 type Alert struct {
@@ -22,12 +56,170 @@ type Alert struct {
 }
 
 type TelegramMessage struct {
-	ChatID    string `json:"chat_id"`
-	Text      string `json:"text"`
-	ParseMode string `json:"parse_mode"`
+	ChatID      string                `json:"chat_id"`
+	MessageID   int                   `json:"message_id,omitempty"`
+	Text        string                `json:"text"`
+	ParseMode   string                `json:"parse_mode"`
+	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data,omitempty"`
+	URL          string `json:"url,omitempty"`
+}
+
+// TelegramUpdate is the update Telegram posts to the bot webhook. Only
+// callback queries from inline keyboards are handled.
+type TelegramUpdate struct {
+	UpdateID      int                    `json:"update_id"`
+	CallbackQuery *TelegramCallbackQuery `json:"callback_query,omitempty"`
+}
+
+type TelegramCallbackQuery struct {
+	ID   string `json:"id"`
+	From struct {
+		ID        int64  `json:"id"`
+		Username  string `json:"username"`
+		FirstName string `json:"first_name"`
+	} `json:"from"`
+	Message *struct {
+		MessageID int `json:"message_id"`
+		Chat      struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+		Text     string                  `json:"text"`
+		Entities []TelegramMessageEntity `json:"entities,omitempty"`
+	} `json:"message,omitempty"`
+	Data string `json:"data"`
+}
+
+// TelegramMessageEntity marks formatting in a message text. Offset and Length
+// count UTF-16 code units.
+type TelegramMessageEntity struct {
+	Type     string `json:"type"`
+	Offset   int    `json:"offset"`
+	Length   int    `json:"length"`
+	URL      string `json:"url,omitempty"`
+	Language string `json:"language,omitempty"`
 }
 
 type DiscordMessage struct {
 	Content   string `json:"content"`
 	AvatarURL string `json:"avatar_url,omitempty"`
 }
+
+type SlackMessage struct {
+	Channel string       `json:"channel"`
+	TS      string       `json:"ts,omitempty"`
+	Text    string       `json:"text"`
+	Blocks  []SlackBlock `json:"blocks,omitempty"`
+}
+
+// SlackBlock is a Block Kit layout block. Only the fields used by this server
+// are modelled.
+type SlackBlock struct {
+	Type     string         `json:"type"`
+	BlockID  string         `json:"block_id,omitempty"`
+	Text     *SlackText     `json:"text,omitempty"`
+	Fields   []SlackText    `json:"fields,omitempty"`
+	Elements []SlackElement `json:"elements,omitempty"`
+}
+
+type SlackText struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Emoji bool   `json:"emoji,omitempty"`
+}
+
+// SlackElement is either a button inside an actions block or a text element
+// inside a context block.
+type SlackElement struct {
+	Type     string      `json:"type"`
+	Text     interface{} `json:"text,omitempty"`
+	ActionID string      `json:"action_id,omitempty"`
+	Value    string      `json:"value,omitempty"`
+	Style    string      `json:"style,omitempty"`
+	URL      string      `json:"url,omitempty"`
+}
+
+// SlackInteraction is the "block_actions" payload Slack posts to the
+// interactivity request URL.
+type SlackInteraction struct {
+	Type string `json:"type"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+		Name     string `json:"name"`
+	} `json:"user"`
+	Channel struct {
+		ID string `json:"id"`
+	} `json:"channel"`
+	Message struct {
+		TS     string       `json:"ts"`
+		Text   string       `json:"text"`
+		Blocks []SlackBlock `json:"blocks"`
+	} `json:"message"`
+	ResponseURL string `json:"response_url"`
+	Actions     []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+}
+
+// SlackResponse is posted to the response_url of an interaction. An
+// ephemeral response is only shown to the user who clicked.
+type SlackResponse struct {
+	ResponseType    string `json:"response_type"`
+	ReplaceOriginal bool   `json:"replace_original"`
+	Text            string `json:"text"`
+}
+
+// TeamsMessage wraps an Adaptive Card for a Teams incoming webhook or
+// Workflows trigger URL.
+type TeamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []TeamsAttachment `json:"attachments"`
+}
+
+type TeamsAttachment struct {
+	ContentType string       `json:"contentType"`
+	ContentURL  *string      `json:"contentUrl"`
+	Content     AdaptiveCard `json:"content"`
+}
+
+type AdaptiveCard struct {
+	Schema  string            `json:"$schema"`
+	Type    string            `json:"type"`
+	Version string            `json:"version"`
+	Body    []AdaptiveElement `json:"body"`
+	Actions []AdaptiveAction  `json:"actions,omitempty"`
+	MSTeams map[string]string `json:"msteams,omitempty"`
+}
+
+// AdaptiveElement covers the TextBlock and FactSet elements used in alert
+// cards.
+type AdaptiveElement struct {
+	Type   string         `json:"type"`
+	Text   string         `json:"text,omitempty"`
+	Size   string         `json:"size,omitempty"`
+	Weight string         `json:"weight,omitempty"`
+	Color  string         `json:"color,omitempty"`
+	Wrap   bool           `json:"wrap,omitempty"`
+	Facts  []AdaptiveFact `json:"facts,omitempty"`
+}
+
+type AdaptiveFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+type AdaptiveAction struct {
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
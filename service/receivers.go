@@ -0,0 +1,256 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/bwmarrin/discordgo"
+
+	"webhook-server/service/config"
+	"webhook-server/service/contact"
+	"webhook-server/service/helper"
+	"webhook-server/service/rest"
+	"webhook-server/service/route"
+)
+
+// enabledChannels lists the channels configured in the environment.
+func enabledChannels(config *config.Config) []string {
+	channels := []string{route.ReceiverDiscord}
+	if config.TelegramDisabled != "true" {
+		channels = append(channels, route.ReceiverTelegram)
+	}
+	if config.SlackBotToken != "" {
+		channels = append(channels, route.ReceiverSlack)
+	}
+	if config.TeamsWebhookURL != "" {
+		channels = append(channels, route.ReceiverTeams)
+	}
+	if config.SMTPHost != "" {
+		channels = append(channels, route.ReceiverEmail)
+	}
+	return channels
+}
+
+// loadRouting reads ROUTING_CONFIG, or builds a tree that sends every alert
+// to each channel enabled in the environment when it is not set.
+func loadRouting(config *config.Config) (*route.Config, error) {
+	if config.RoutingConfig != "" {
+		return route.Load(config.RoutingConfig)
+	}
+
+	routing := &route.Config{Route: &route.Route{}}
+	for _, name := range enabledChannels(config) {
+		routing.Receivers = append(routing.Receivers, route.Receiver{Name: name, Type: name})
+		routing.Route.Routes = append(routing.Route.Routes, &route.Route{Receiver: name, Continue: true})
+	}
+
+	if err := routing.Validate(); err != nil {
+		return nil, err
+	}
+	return routing, nil
+}
+
+// buildReceivers populates the receiver registry from the routing config.
+func buildReceivers(config *config.Config, routing *route.Config, server *rest.RestController, discord *discordgo.Session) (map[string]*rest.Receiver, error) {
+	receivers := make(map[string]*rest.Receiver)
+	for _, r := range routing.Receivers {
+		receiver, err := newReceiver(config, r, server, discord)
+		if err != nil {
+			return nil, err
+		}
+		receivers[r.Name] = receiver
+	}
+	return receivers, nil
+}
+
+// buildChannels creates the receivers behind the per-channel endpoints such as
+// /telegram, using only the defaults from the environment.
+func buildChannels(config *config.Config, server *rest.RestController, discord *discordgo.Session) (map[string]*rest.Receiver, error) {
+	channels := make(map[string]*rest.Receiver)
+	for _, name := range enabledChannels(config) {
+		channel, err := newReceiver(config, route.Receiver{Name: name, Type: name}, server, discord)
+		if err != nil {
+			return nil, err
+		}
+		channels[name] = channel
+	}
+	return channels, nil
+}
+
+// newReceiver creates the notifier and delivery policy for a receiver.
+func newReceiver(config *config.Config, r route.Receiver, server *rest.RestController, discord *discordgo.Session) (*rest.Receiver, error) {
+	defaults, err := defaultRetryPolicy(config)
+	if err != nil {
+		return nil, err
+	}
+	retry, err := retryPolicy(defaults, r.Retry)
+	if err != nil {
+		return nil, fmt.Errorf("invalid retry policy for receiver %s: %w", r.Name, err)
+	}
+
+	concurrency, err := defaultConcurrency(config)
+	if err != nil {
+		return nil, err
+	}
+	if r.Concurrency < 0 {
+		return nil, fmt.Errorf("invalid concurrency for receiver %s", r.Name)
+	}
+	if r.Concurrency > 0 {
+		concurrency = r.Concurrency
+	}
+
+	notifier, err := newNotifier(config, r, server, discord)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rest.Receiver{
+		Name:        r.Name,
+		Type:        r.Type,
+		Notifier:    notifier,
+		Retry:       retry,
+		Concurrency: concurrency,
+	}, nil
+}
+
+// newNotifier creates the notifier for the receiver type, filling empty
+// targets from the environment defaults.
+func newNotifier(config *config.Config, r route.Receiver, server *rest.RestController, discord *discordgo.Session) (rest.INotifier, error) {
+	switch r.Type {
+	case route.ReceiverTelegram:
+		return server.NewTelegramNotifier(r.Name, &contact.TelegramSender{ChatID: r.ChatID}), nil
+	case route.ReceiverDiscord:
+		channelID := firstNonEmpty(r.ChannelID, config.DiscordChannelID)
+		sender := &contact.DiscordSender{
+			Discord:   discord,
+			ChannelID: channelID,
+		}
+		return server.NewDiscordNotifier(r.Name, sender, channelID), nil
+	case route.ReceiverSlack:
+		if config.SlackBotToken == "" {
+			return nil, fmt.Errorf("receiver %s needs SLACK_BOT_TOKEN", r.Name)
+		}
+		return server.NewSlackNotifier(r.Name, &contact.SlackSender{
+			BotToken:  config.SlackBotToken,
+			ChannelID: firstNonEmpty(r.ChannelID, config.SlackChannelID),
+		}), nil
+	case route.ReceiverTeams:
+		webhookURL := firstNonEmpty(r.WebhookURL, config.TeamsWebhookURL)
+		if webhookURL == "" {
+			return nil, fmt.Errorf("receiver %s needs a webhook_url or TEAMS_WEBHOOK_URL", r.Name)
+		}
+		return server.NewTeamsNotifier(r.Name, &contact.TeamsSender{WebhookURL: webhookURL}), nil
+	case route.ReceiverEmail:
+		if config.SMTPHost == "" {
+			return nil, fmt.Errorf("receiver %s needs SMTP_HOST", r.Name)
+		}
+		to := r.To
+		if len(to) == 0 {
+			to = config.SMTPTo
+		}
+		return server.NewEmailNotifier(r.Name, &contact.EmailSender{
+			Host:     config.SMTPHost,
+			Port:     config.SMTPPort,
+			Username: config.SMTPUsername,
+			Password: config.SMTPPassword,
+			From:     config.SMTPFrom,
+			To:       to,
+			StartTLS: config.SMTPStartTLS != "false",
+		}), nil
+	}
+	return nil, fmt.Errorf("receiver %s has unknown type %q", r.Name, r.Type)
+}
+
+// defaultRetryPolicy reads the delivery retry settings from the environment.
+func defaultRetryPolicy(config *config.Config) (rest.RetryPolicy, error) {
+	maxAttempts, err := strconv.Atoi(config.DeliveryMaxAttempts)
+	if err != nil || maxAttempts < 1 {
+		return rest.RetryPolicy{}, fmt.Errorf("invalid DELIVERY_MAX_ATTEMPTS %q", config.DeliveryMaxAttempts)
+	}
+	initialBackoff, err := helper.ParseDuration(config.DeliveryInitialBackoff)
+	if err != nil || initialBackoff <= 0 {
+		return rest.RetryPolicy{}, fmt.Errorf("invalid DELIVERY_INITIAL_BACKOFF %q", config.DeliveryInitialBackoff)
+	}
+	maxBackoff, err := helper.ParseDuration(config.DeliveryMaxBackoff)
+	if err != nil || maxBackoff < initialBackoff {
+		return rest.RetryPolicy{}, fmt.Errorf("invalid DELIVERY_MAX_BACKOFF %q", config.DeliveryMaxBackoff)
+	}
+
+	return rest.RetryPolicy{
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: initialBackoff,
+		MaxBackoff:     maxBackoff,
+	}, nil
+}
+
+func defaultConcurrency(config *config.Config) (int, error) {
+	concurrency, err := strconv.Atoi(config.DeliveryConcurrency)
+	if err != nil || concurrency < 1 {
+		return 0, fmt.Errorf("invalid DELIVERY_CONCURRENCY %q", config.DeliveryConcurrency)
+	}
+	return concurrency, nil
+}
+
+// deliverySettings reads the worker pool size from the environment.
+func deliverySettings(config *config.Config) (rest.DeliverySettings, error) {
+	workers, err := strconv.Atoi(config.DeliveryWorkers)
+	if err != nil || workers < 1 {
+		return rest.DeliverySettings{}, fmt.Errorf("invalid DELIVERY_WORKERS %q", config.DeliveryWorkers)
+	}
+	queueSize, err := strconv.Atoi(config.DeliveryQueueSize)
+	if err != nil || queueSize < 1 {
+		return rest.DeliverySettings{}, fmt.Errorf("invalid DELIVERY_QUEUE_SIZE %q", config.DeliveryQueueSize)
+	}
+	pollInterval, err := helper.ParseDuration(config.DeliveryPollInterval)
+	if err != nil || pollInterval <= 0 {
+		return rest.DeliverySettings{}, fmt.Errorf("invalid DELIVERY_POLL_INTERVAL %q", config.DeliveryPollInterval)
+	}
+
+	return rest.DeliverySettings{
+		Workers:      workers,
+		QueueSize:    queueSize,
+		PollInterval: pollInterval,
+	}, nil
+}
+
+// retryPolicy applies a receiver's overrides to the default policy.
+func retryPolicy(defaults rest.RetryPolicy, retry *route.Retry) (rest.RetryPolicy, error) {
+	policy := defaults
+	if retry == nil {
+		return policy, nil
+	}
+
+	if retry.MaxAttempts < 0 {
+		return policy, fmt.Errorf("max_attempts must be positive")
+	}
+	if retry.MaxAttempts > 0 {
+		policy.MaxAttempts = retry.MaxAttempts
+	}
+	if retry.InitialBackoff != "" {
+		backoff, err := helper.ParseDuration(retry.InitialBackoff)
+		if err != nil || backoff <= 0 {
+			return policy, fmt.Errorf("invalid initial_backoff %q", retry.InitialBackoff)
+		}
+		policy.InitialBackoff = backoff
+	}
+	if retry.MaxBackoff != "" {
+		backoff, err := helper.ParseDuration(retry.MaxBackoff)
+		if err != nil || backoff <= 0 {
+			return policy, fmt.Errorf("invalid max_backoff %q", retry.MaxBackoff)
+		}
+		policy.MaxBackoff = backoff
+	}
+	if policy.MaxBackoff < policy.InitialBackoff {
+		return policy, fmt.Errorf("max_backoff must not be shorter than initial_backoff")
+	}
+	return policy, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
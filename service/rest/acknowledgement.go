@@ -0,0 +1,160 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"webhook-server/service/config"
+	"webhook-server/service/model"
+)
+
+const (
+	acknowledgedAlertsCollection = "acknowledged_alerts"
+	ackKeyPrefix                 = "ack:"
+)
+
+// AlertAcknowledgement records who took ownership of a firing alert. Repeat
+// notifications for the alert stop until it resolves.
+type AlertAcknowledgement struct {
+	Fingerprint    string    `bson:"fingerprint" json:"fingerprint"`
+	AcknowledgedBy string    `bson:"acknowledged_by" json:"acknowledged_by"`
+	AcknowledgedAt time.Time `bson:"acknowledged_at" json:"acknowledged_at"`
+}
+
+func (rc *RestController) acknowledgedAlerts() (*mongo.Collection, error) {
+	config, err := config.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+	return rc.MongoClient.Database(config.MongoDBDatabase).Collection(acknowledgedAlertsCollection), nil
+}
+
+// acknowledgeAlert makes the user the owner of the alert, taking over from a
+// previous owner.
+func (rc *RestController) acknowledgeAlert(ctx context.Context, fingerprint, user string) (*AlertAcknowledgement, error) {
+	collection, err := rc.acknowledgedAlerts()
+	if err != nil {
+		return nil, err
+	}
+
+	ack := AlertAcknowledgement{
+		Fingerprint:    fingerprint,
+		AcknowledgedBy: user,
+		AcknowledgedAt: time.Now(),
+	}
+	_, err = collection.ReplaceOne(ctx, bson.M{"fingerprint": fingerprint}, ack, options.Replace().SetUpsert(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to acknowledge alert: %w", err)
+	}
+	return &ack, nil
+}
+
+// findAcknowledgement returns the acknowledgement of the alert, or nil if
+// nobody took it.
+func (rc *RestController) findAcknowledgement(ctx context.Context, fingerprint string) (*AlertAcknowledgement, error) {
+	collection, err := rc.acknowledgedAlerts()
+	if err != nil {
+		return nil, err
+	}
+
+	var result AlertAcknowledgement
+	err = collection.FindOne(ctx, bson.M{"fingerprint": fingerprint}).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find acknowledgement: %w", err)
+	}
+	return &result, nil
+}
+
+// alertOwner returns who took the alert, or an empty string when nobody did
+// or the lookup failed, so buttons can be rebuilt either way.
+func (rc *RestController) alertOwner(ctx context.Context, fingerprint string) string {
+	if fingerprint == "" {
+		return ""
+	}
+	ack, err := rc.findAcknowledgement(ctx, fingerprint)
+	if err != nil {
+		log.Printf("Error finding acknowledgement: %v", err)
+		return ""
+	}
+	if ack == nil {
+		return ""
+	}
+	return ack.AcknowledgedBy
+}
+
+// listAcknowledgements returns the acknowledgements by fingerprint.
+func (rc *RestController) listAcknowledgements(ctx context.Context) (map[string]AlertAcknowledgement, error) {
+	collection, err := rc.acknowledgedAlerts()
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list acknowledgements: %w", err)
+	}
+
+	var results []AlertAcknowledgement
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode acknowledgements: %w", err)
+	}
+	acks := make(map[string]AlertAcknowledgement, len(results))
+	for _, ack := range results {
+		acks[ack.Fingerprint] = ack
+	}
+	return acks, nil
+}
+
+// isAcknowledged reports whether a firing alert has an owner, so its repeat
+// notifications are skipped. Resolved alerts are always delivered.
+func (rc *RestController) isAcknowledged(ctx context.Context, alert model.Alert) (bool, error) {
+	if alert.Status != "firing" || alert.Fingerprint == "" {
+		return false, nil
+	}
+
+	ack, err := rc.findAcknowledgement(ctx, alert.Fingerprint)
+	if err != nil {
+		return false, err
+	}
+	if ack != nil {
+		log.Printf("Alert %s acknowledged by %s at %v", alert.Fingerprint, ack.AcknowledgedBy, ack.AcknowledgedAt)
+		return true, nil
+	}
+	return false, nil
+}
+
+// clearAcknowledgement forgets the owner of a resolved alert, so the next
+// incident notifies again. It runs at the same points as clearSuppression.
+func (rc *RestController) clearAcknowledgement(ctx context.Context, alert model.Alert) {
+	if alert.Status != "resolved" || alert.Fingerprint == "" {
+		return
+	}
+
+	collection, err := rc.acknowledgedAlerts()
+	if err == nil {
+		_, err = collection.DeleteOne(ctx, bson.M{"fingerprint": alert.Fingerprint})
+	}
+	if err != nil {
+		log.Printf("Error removing acknowledgement: %v", err)
+	}
+}
+
+// ackFingerprint returns the fingerprint of the alert behind an acknowledge
+// button.
+func ackFingerprint(data string) (string, error) {
+	fingerprint, found := strings.CutPrefix(data, ackKeyPrefix)
+	if !found || fingerprint == "" {
+		return "", fmt.Errorf("invalid key %q", data)
+	}
+	return fingerprint, nil
+}
@@ -0,0 +1,108 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"webhook-server/service/config"
+	"webhook-server/service/model"
+)
+
+const alertsCollection = "alerts"
+
+// AlertRecord is the latest known state of an alert, keyed by fingerprint, so
+// chat buttons only need to carry the fingerprint.
+type AlertRecord struct {
+	Fingerprint string            `bson:"fingerprint" json:"fingerprint"`
+	Labels      map[string]string `bson:"labels" json:"labels"`
+	Annotations map[string]string `bson:"annotations" json:"annotations"`
+	Status      string            `bson:"status" json:"status"`
+	StartsAt    time.Time         `bson:"starts_at" json:"starts_at"`
+	EndsAt      time.Time         `bson:"ends_at" json:"ends_at"`
+	UpdatedAt   time.Time         `bson:"updated_at" json:"updated_at"`
+}
+
+func (rc *RestController) alertRecords() (*mongo.Collection, error) {
+	config, err := config.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+	return rc.MongoClient.Database(config.MongoDBDatabase).Collection(alertsCollection), nil
+}
+
+// recordAlerts stores the received alerts. Failures are only logged since
+// delivery does not depend on them.
+func (rc *RestController) recordAlerts(ctx context.Context, alerts []model.Alert) {
+	collection, err := rc.alertRecords()
+	if err != nil {
+		log.Printf("Error recording alerts: %v", err)
+		return
+	}
+
+	for _, alert := range alerts {
+		if alert.Fingerprint == "" {
+			continue
+		}
+		_, err := collection.ReplaceOne(
+			ctx,
+			bson.M{"fingerprint": alert.Fingerprint},
+			AlertRecord{
+				Fingerprint: alert.Fingerprint,
+				Labels:      alert.Labels,
+				Annotations: alert.Annotations,
+				Status:      alert.Status,
+				StartsAt:    alert.StartsAt,
+				EndsAt:      alert.EndsAt,
+				UpdatedAt:   time.Now(),
+			},
+			options.Replace().SetUpsert(true),
+		)
+		if err != nil {
+			log.Printf("Error recording alert %s: %v", alert.Fingerprint, err)
+		}
+	}
+}
+
+// findAlertRecord returns the alert with the fingerprint, or nil if it was
+// never received.
+func (rc *RestController) findAlertRecord(ctx context.Context, fingerprint string) (*AlertRecord, error) {
+	collection, err := rc.alertRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	var result AlertRecord
+	err = collection.FindOne(ctx, bson.M{"fingerprint": fingerprint}).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find alert: %w", err)
+	}
+	return &result, nil
+}
+
+// listAlertRecords returns the alerts matching the filter, oldest first.
+func (rc *RestController) listAlertRecords(ctx context.Context, filter bson.M) ([]AlertRecord, error) {
+	collection, err := rc.alertRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.M{"starts_at": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list alerts: %w", err)
+	}
+
+	results := []AlertRecord{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode alerts: %w", err)
+	}
+	return results, nil
+}
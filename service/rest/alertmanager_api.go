@@ -0,0 +1,243 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"webhook-server/service/matcher"
+)
+
+// Alert states reported by the Alertmanager API
+const (
+	alertStateActive     = "active"
+	alertStateSuppressed = "suppressed"
+)
+
+// startedAt is reported as the uptime in /api/v2/status.
+var startedAt = time.Now()
+
+type clusterStatus struct {
+	Status string   `json:"status"`
+	Peers  []string `json:"peers"`
+}
+
+type versionInfo struct {
+	Branch    string `json:"branch"`
+	BuildDate string `json:"buildDate"`
+	BuildUser string `json:"buildUser"`
+	GoVersion string `json:"goVersion"`
+	Revision  string `json:"revision"`
+	Version   string `json:"version"`
+}
+
+// alertmanagerStatus is the Alertmanager API v2 status. Grafana's
+// Alertmanager data source checks it when the data source is saved.
+type alertmanagerStatus struct {
+	Cluster     clusterStatus     `json:"cluster"`
+	Config      map[string]string `json:"config"`
+	Uptime      time.Time         `json:"uptime"`
+	VersionInfo versionInfo       `json:"versionInfo"`
+}
+
+type alertReceiver struct {
+	Name string `json:"name"`
+}
+
+type alertStatus struct {
+	State       string   `json:"state"`
+	SilencedBy  []string `json:"silencedBy"`
+	InhibitedBy []string `json:"inhibitedBy"`
+}
+
+// gettableAlert is a firing alert in the Alertmanager API v2 format.
+type gettableAlert struct {
+	Fingerprint string            `json:"fingerprint"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	Receivers   []alertReceiver   `json:"receivers"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt"`
+	UpdatedAt   time.Time         `json:"updatedAt"`
+	Status      alertStatus       `json:"status"`
+}
+
+// alertGroup is the Alertmanager API v2 alert group. Alerts are grouped by
+// receiver only, since the routing file has no group_by.
+type alertGroup struct {
+	Labels   map[string]string `json:"labels"`
+	Receiver alertReceiver     `json:"receiver"`
+	Alerts   []gettableAlert   `json:"alerts"`
+}
+
+// StatusHandler implements GET /api/v2/status.
+func (rc *RestController) StatusHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, alertmanagerStatus{
+		Cluster:     clusterStatus{Status: "disabled", Peers: []string{}},
+		Config:      map[string]string{"original": ""},
+		Uptime:      startedAt,
+		VersionInfo: versionInfo{GoVersion: runtime.Version()},
+	})
+}
+
+// ListAlertsHandler implements GET /api/v2/alerts, listing the firing alerts
+// with the filter, active, silenced and receiver parameters of Alertmanager.
+// Nothing is inhibited, so the inhibited parameter is ignored.
+func (rc *RestController) ListAlertsHandler(w http.ResponseWriter, r *http.Request) {
+	query, err := parseAlertsQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	alerts, err := rc.firingAlerts(r.Context(), query)
+	if err != nil {
+		log.Printf("Error listing alerts: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, alerts)
+}
+
+// ListAlertGroupsHandler implements GET /api/v2/alerts/groups with the same
+// parameters as ListAlertsHandler.
+func (rc *RestController) ListAlertGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	query, err := parseAlertsQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	alerts, err := rc.firingAlerts(r.Context(), query)
+	if err != nil {
+		log.Printf("Error listing alerts: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	byReceiver := map[string][]gettableAlert{}
+	for _, alert := range alerts {
+		for _, receiver := range alert.Receivers {
+			byReceiver[receiver.Name] = append(byReceiver[receiver.Name], alert)
+		}
+	}
+
+	groups := []alertGroup{}
+	for name, alerts := range byReceiver {
+		groups = append(groups, alertGroup{
+			Labels:   map[string]string{},
+			Receiver: alertReceiver{Name: name},
+			Alerts:   alerts,
+		})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Receiver.Name < groups[j].Receiver.Name
+	})
+	writeJSON(w, http.StatusOK, groups)
+}
+
+// alertsQuery selects alerts like the Alertmanager API parameters.
+type alertsQuery struct {
+	filter   matcher.Matchers
+	active   bool
+	silenced bool
+	receiver *regexp.Regexp
+}
+
+func parseAlertsQuery(r *http.Request) (*alertsQuery, error) {
+	values := r.URL.Query()
+	filter, err := matcher.ParseAll(values["filter"])
+	if err != nil {
+		return nil, err
+	}
+	query := &alertsQuery{filter: filter}
+	if query.active, err = boolParam(values.Get("active")); err != nil {
+		return nil, err
+	}
+	if query.silenced, err = boolParam(values.Get("silenced")); err != nil {
+		return nil, err
+	}
+	if receiver := values.Get("receiver"); receiver != "" {
+		query.receiver, err = regexp.Compile("^(?:" + receiver + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid receiver %q: %w", receiver, err)
+		}
+	}
+	return query, nil
+}
+
+// firingAlerts returns the firing alerts selected by the query, with the
+// receivers the routing tree sends them to and the silences muting them.
+func (rc *RestController) firingAlerts(ctx context.Context, query *alertsQuery) ([]gettableAlert, error) {
+	records, err := rc.listAlertRecords(ctx, bson.M{"status": "firing"})
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	suppressions, err := rc.listSuppressions(ctx, bson.M{"suppressed_until": bson.M{"$gt": now}})
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := []gettableAlert{}
+	for _, record := range records {
+		if !query.filter.Matches(record.Labels) {
+			continue
+		}
+
+		receivers := []alertReceiver{}
+		matched := query.receiver == nil
+		if rc.Router != nil {
+			for _, name := range rc.Router.Receivers(record.Labels) {
+				receivers = append(receivers, alertReceiver{Name: name})
+				matched = matched || query.receiver.MatchString(name)
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		status := alertStatus{State: alertStateActive, SilencedBy: []string{}, InhibitedBy: []string{}}
+		for i := range suppressions {
+			if suppressions[i].IsActive(now) && suppressions[i].LabelMatchers().Matches(record.Labels) {
+				status.SilencedBy = append(status.SilencedBy, suppressions[i].ID.Hex())
+			}
+		}
+		if len(status.SilencedBy) > 0 {
+			status.State = alertStateSuppressed
+		}
+		if (status.State == alertStateActive && !query.active) || (status.State == alertStateSuppressed && !query.silenced) {
+			continue
+		}
+
+		alerts = append(alerts, gettableAlert{
+			Fingerprint: record.Fingerprint,
+			Labels:      record.Labels,
+			Annotations: record.Annotations,
+			Receivers:   receivers,
+			StartsAt:    record.StartsAt,
+			EndsAt:      record.EndsAt,
+			UpdatedAt:   record.UpdatedAt,
+			Status:      status,
+		})
+	}
+	return alerts, nil
+}
+
+// boolParam parses an optional boolean query parameter, true when missing.
+func boolParam(value string) (bool, error) {
+	if value == "" {
+		return true, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid boolean %q", value)
+	}
+	return b, nil
+}
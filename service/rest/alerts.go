@@ -0,0 +1,124 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+
+	"webhook-server/service/model"
+)
+
+// Receiver is a named notification target in the registry, with the policy
+// used to deliver to it.
+type Receiver struct {
+	Name        string
+	Type        string
+	Notifier    INotifier
+	Retry       RetryPolicy
+	Concurrency int
+}
+
+// AlertsWebhookHandler delivers each alert to the receivers chosen by the
+// routing tree for its labels.
+func (rc *RestController) AlertsWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if rc.Router == nil {
+		http.Error(w, "Routing not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	rc.ingestWebhook(w, r, false, func(alert model.Alert) []*Receiver {
+		var receivers []*Receiver
+		for _, name := range rc.Router.Receivers(alert.Labels) {
+			receiver, ok := rc.Receivers[name]
+			if !ok {
+				log.Printf("Unknown receiver %s for alert %s", name, alert.Fingerprint)
+				continue
+			}
+			receivers = append(receivers, receiver)
+		}
+		return receivers
+	})
+}
+
+func (rc *RestController) deliver(ctx context.Context, receiver *Receiver, alert model.Alert) error {
+	return receiver.Notifier.Send(ctx, Notification{Alert: alert})
+}
+
+// ReceiverWebhookHandler delivers every alert to the receiver named in the
+// path, bypassing the routing tree.
+func (rc *RestController) ReceiverWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	receiver, ok := rc.Receivers[r.PathValue("receiver")]
+	if !ok {
+		http.Error(w, "Unknown receiver", http.StatusNotFound)
+		return
+	}
+
+	rc.ingestWebhook(w, r, false, func(model.Alert) []*Receiver {
+		return []*Receiver{receiver}
+	})
+}
+
+// ChannelWebhookHandler serves the per-channel endpoints such as /telegram,
+// which send to the channel defaults from the environment.
+func (rc *RestController) ChannelWebhookHandler(channel string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		receiver, ok := rc.Channels[channel]
+		if !ok {
+			http.Error(w, fmt.Sprintf("Notifications disabled for %s", channel), http.StatusServiceUnavailable)
+			return
+		}
+
+		rc.ingestWebhook(w, r, true, func(model.Alert) []*Receiver {
+			return []*Receiver{receiver}
+		})
+	}
+}
+
+// receiverInfo describes a registered receiver for the API.
+type receiverInfo struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Channel     bool   `json:"channel"`
+	MaxAttempts int    `json:"max_attempts"`
+	Concurrency int    `json:"concurrency"`
+}
+
+// ListReceiversHandler lists the routing receivers and channel defaults with
+// their delivery settings.
+func (rc *RestController) ListReceiversHandler(w http.ResponseWriter, r *http.Request) {
+	receivers := []receiverInfo{}
+	add := func(channel bool, registry map[string]*Receiver) {
+		names := make([]string, 0, len(registry))
+		for name := range registry {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			receiver := registry[name]
+			receivers = append(receivers, receiverInfo{
+				Name:        receiver.Name,
+				Type:        receiver.Type,
+				Channel:     channel,
+				MaxAttempts: receiver.Retry.MaxAttempts,
+				Concurrency: receiver.Concurrency,
+			})
+		}
+	}
+	add(false, rc.Receivers)
+	add(true, rc.Channels)
+
+	writeJSON(w, http.StatusOK, receivers)
+}
@@ -0,0 +1,95 @@
+package rest
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// deliveryFilter builds a filter from the receiver and fingerprint query
+// parameters.
+func deliveryFilter(r *http.Request) bson.M {
+	query := r.URL.Query()
+	filter := bson.M{}
+	if receiver := query.Get("receiver"); receiver != "" {
+		filter["receiver"] = receiver
+	}
+	if fingerprint := query.Get("fingerprint"); fingerprint != "" {
+		filter["alert.fingerprint"] = fingerprint
+	}
+	return filter
+}
+
+// ListDeliveriesHandler lists deliveries waiting for their next attempt.
+func (rc *RestController) ListDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	deliveries, err := rc.listQueuedDeliveries(r.Context(), deliveryFilter(r))
+	if err != nil {
+		log.Printf("Error listing deliveries: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+// ListDeadLettersHandler lists deliveries that ran out of attempts.
+func (rc *RestController) ListDeadLettersHandler(w http.ResponseWriter, r *http.Request) {
+	deadLetters, err := rc.listDeadLetters(r.Context(), deliveryFilter(r))
+	if err != nil {
+		log.Printf("Error listing dead letters: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, deadLetters)
+}
+
+// ReplayDeadLetterHandler moves a dead letter back to the queue. The delivery
+// worker sends it on its next run.
+func (rc *RestController) ReplayDeadLetterHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid dead letter ID", http.StatusBadRequest)
+		return
+	}
+
+	job, err := rc.replayDeadLetter(r.Context(), id)
+	if errors.Is(err, errStaleDeadLetter) {
+		http.Error(w, "A newer notification for this alert exists", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("Error replaying dead letter: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if job == nil {
+		http.Error(w, "Dead letter not found", http.StatusNotFound)
+		return
+	}
+
+	log.Printf("Replaying dead letter %s for %s as delivery %s", id.Hex(), job.Receiver, job.ID.Hex())
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+func (rc *RestController) DeleteDeadLetterHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid dead letter ID", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := rc.deleteDeadLetter(r.Context(), id)
+	if err != nil {
+		log.Printf("Error deleting dead letter: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		http.Error(w, "Dead letter not found", http.StatusNotFound)
+		return
+	}
+	log.Printf("Deleted dead letter %s via API", id.Hex())
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,453 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"webhook-server/service/config"
+	"webhook-server/service/model"
+)
+
+const (
+	deliveryQueueCollection = "delivery_queue"
+	deadLettersCollection   = "delivery_dead_letters"
+
+	// deliveryLease is how long a claimed job stays hidden from other workers
+	// while it is being sent. It must outlast the senders' own timeouts.
+	deliveryLease = 2 * time.Minute
+	// deliveryAttemptTimeout bounds one send, well within the lease.
+	deliveryAttemptTimeout = time.Minute
+	// deliveryRecordTimeout bounds storing the result of a send, which still
+	// happens when shutdown cancelled the send itself.
+	deliveryRecordTimeout = 10 * time.Second
+)
+
+// RetryPolicy controls how often a failed delivery is retried before it is
+// moved to the dead letters. The wait doubles after every attempt.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+func (p RetryPolicy) backoff(attempts int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < attempts && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	return backoff
+}
+
+// DeliveryJob is one alert waiting to be sent to one receiver. Channel jobs
+// come from the per-channel endpoints such as /telegram and name an entry of
+// Channels instead of a routing receiver.
+type DeliveryJob struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Receiver      string             `bson:"receiver" json:"receiver"`
+	Channel       bool               `bson:"channel" json:"channel"`
+	IngestionID   string             `bson:"ingestion_id,omitempty" json:"ingestion_id,omitempty"`
+	AlertIndex    int                `bson:"alert_index" json:"alert_index"`
+	Alert         model.Alert        `bson:"alert" json:"alert"`
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	LastError     string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at" json:"next_attempt_at"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	FailedAt      time.Time          `bson:"failed_at,omitempty" json:"failed_at,omitempty"`
+	// LeaseID changes every time the job is handed to a worker, so only the
+	// last holder may send it.
+	LeaseID primitive.ObjectID `bson:"lease_id,omitempty" json:"-"`
+	// Sending is set while a worker sends the job, until its lease expires.
+	Sending bool `bson:"sending,omitempty" json:"sending,omitempty"`
+}
+
+func (rc *RestController) deliveryQueue() (*mongo.Collection, error) {
+	config, err := config.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+	return rc.MongoClient.Database(config.MongoDBDatabase).Collection(deliveryQueueCollection), nil
+}
+
+func (rc *RestController) deadLetters() (*mongo.Collection, error) {
+	config, err := config.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+	return rc.MongoClient.Database(config.MongoDBDatabase).Collection(deadLettersCollection), nil
+}
+
+// dispatch queues the job and hands it to the receiver's workers. When they
+// are busy the job is left for the queue poller.
+func (rc *RestController) dispatch(ctx context.Context, job *DeliveryJob) error {
+	job.CreatedAt = time.Now()
+	job.NextAttemptAt = job.CreatedAt.Add(deliveryLease)
+	job.LeaseID = primitive.NewObjectID()
+	if err := rc.enqueueDelivery(ctx, job, true); err != nil {
+		return err
+	}
+
+	if !rc.push(job) {
+		rc.releaseDelivery(ctx, job)
+	}
+	return nil
+}
+
+// notSending matches jobs no worker is sending right now. A job whose lease
+// expired while sending counts as not sending, since its worker is gone.
+func notSending(now time.Time) bson.M {
+	return bson.M{"$nor": bson.A{bson.M{"sending": true, "next_attempt_at": bson.M{"$gt": now}}}}
+}
+
+// enqueueDelivery stores the job. With supersede, older jobs still waiting for
+// a retry of the same alert to the same receiver are dropped, so a stale
+// firing notification is never sent after its resolution. A job being sent is
+// left to finish; its worker drops it instead of retrying once it sees the
+// newer job.
+func (rc *RestController) enqueueDelivery(ctx context.Context, job *DeliveryJob, supersede bool) error {
+	collection, err := rc.deliveryQueue()
+	if err != nil {
+		return err
+	}
+
+	if supersede && job.Alert.Fingerprint != "" {
+		now := time.Now()
+		filter := notSending(now)
+		filter["receiver"] = job.Receiver
+		filter["channel"] = job.Channel
+		filter["alert.fingerprint"] = job.Alert.Fingerprint
+		filter["created_at"] = bson.M{"$lt": job.CreatedAt}
+		superseded, err := findDeliveries(ctx, collection, filter)
+		if err != nil {
+			return err
+		}
+		for i := range superseded {
+			drop := notSending(now)
+			drop["_id"] = superseded[i].ID
+			result, err := collection.DeleteOne(ctx, drop)
+			if err != nil {
+				return fmt.Errorf("failed to drop superseded delivery: %w", err)
+			}
+			if result.DeletedCount > 0 {
+				rc.recordOutcome(ctx, &superseded[i], outcomeSuperseded, "replaced by a newer notification")
+			}
+		}
+	}
+
+	result, err := collection.InsertOne(ctx, job)
+	if err != nil {
+		return fmt.Errorf("failed to queue delivery: %w", err)
+	}
+	if id, ok := result.InsertedID.(primitive.ObjectID); ok {
+		job.ID = id
+	}
+	return nil
+}
+
+// startDelivery takes a fresh lease on a job a worker is about to send. It
+// reports false when the job was sent, dropped or handed out again since it
+// was queued, e.g. because it waited in a busy lane past its lease.
+func (rc *RestController) startDelivery(ctx context.Context, job *DeliveryJob) (bool, error) {
+	collection, err := rc.deliveryQueue()
+	if err != nil {
+		return false, err
+	}
+
+	leaseID := primitive.NewObjectID()
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": job.ID, "lease_id": job.LeaseID, "attempts": job.Attempts},
+		bson.M{"$set": bson.M{"lease_id": leaseID, "next_attempt_at": time.Now().Add(deliveryLease), "sending": true}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to start delivery: %w", err)
+	}
+	if result.ModifiedCount == 0 {
+		return false, nil
+	}
+	job.LeaseID = leaseID
+	job.Sending = true
+	return true, nil
+}
+
+// hasNewerDelivery reports whether a later job for the same alert and
+// receiver is queued, which makes retrying this one pointless.
+func hasNewerDelivery(ctx context.Context, collection *mongo.Collection, job *DeliveryJob) (bool, error) {
+	if job.Alert.Fingerprint == "" {
+		return false, nil
+	}
+	count, err := collection.CountDocuments(ctx, bson.M{
+		"receiver":          job.Receiver,
+		"channel":           job.Channel,
+		"alert.fingerprint": job.Alert.Fingerprint,
+		"created_at":        bson.M{"$gt": job.CreatedAt},
+	}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("failed to check for newer deliveries: %w", err)
+	}
+	return count > 0, nil
+}
+
+// attemptDelivery sends a claimed job and then removes it, schedules the next
+// attempt or moves it to the dead letters.
+func (rc *RestController) attemptDelivery(ctx context.Context, receiver *Receiver, job *DeliveryJob) {
+	started, err := rc.startDelivery(ctx, job)
+	if err != nil {
+		log.Printf("Error starting delivery %s: %v", job.ID.Hex(), err)
+		return
+	}
+	if !started {
+		log.Printf("Skipping delivery %s of alert %s to %s, already handled", job.ID.Hex(), job.Alert.Fingerprint, job.Receiver)
+		return
+	}
+
+	err = rc.deliver(ctx, receiver, job.Alert)
+	job.Attempts++
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), deliveryRecordTimeout)
+	defer cancel()
+
+	collection, collErr := rc.deliveryQueue()
+	if collErr != nil {
+		log.Printf("Error updating delivery %s: %v", job.ID.Hex(), collErr)
+		return
+	}
+
+	if err == nil {
+		if job.Attempts > 1 {
+			log.Printf("Delivered alert %s to %s after %d attempts", job.Alert.Fingerprint, job.Receiver, job.Attempts)
+		}
+		if _, err := collection.DeleteOne(ctx, bson.M{"_id": job.ID}); err != nil {
+			log.Printf("Error removing delivery %s: %v", job.ID.Hex(), err)
+		}
+		rc.recordOutcome(ctx, job, outcomeSent, "")
+		rc.clearSuppression(ctx, job.Alert)
+		rc.clearAcknowledgement(ctx, job.Alert)
+		return
+	}
+
+	job.LastError = err.Error()
+	newer, checkErr := hasNewerDelivery(ctx, collection, job)
+	if checkErr != nil {
+		log.Printf("Error updating delivery %s: %v", job.ID.Hex(), checkErr)
+	}
+	if newer {
+		log.Printf("Dropping delivery %s of alert %s to %s, superseded while sending: %s", job.ID.Hex(), job.Alert.Fingerprint, job.Receiver, job.LastError)
+		if _, err := collection.DeleteOne(ctx, bson.M{"_id": job.ID, "lease_id": job.LeaseID}); err != nil {
+			log.Printf("Error removing delivery %s: %v", job.ID.Hex(), err)
+		}
+		rc.recordOutcome(ctx, job, outcomeSuperseded, "replaced by a newer notification")
+		return
+	}
+	if job.Attempts >= receiver.Retry.MaxAttempts {
+		log.Printf("Giving up on alert %s for %s after %d attempts: %v", job.Alert.Fingerprint, job.Receiver, job.Attempts, err)
+		rc.deadLetter(ctx, job)
+		return
+	}
+	rc.recordOutcome(ctx, job, outcomeRetrying, job.LastError)
+
+	job.NextAttemptAt = time.Now().Add(receiver.Retry.backoff(job.Attempts))
+	log.Printf("Error sending alert %s to %s (attempt %d), retrying at %v: %v",
+		job.Alert.Fingerprint, job.Receiver, job.Attempts, job.NextAttemptAt, err)
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": job.ID, "lease_id": job.LeaseID}, bson.M{"$set": bson.M{
+		"attempts":        job.Attempts,
+		"last_error":      job.LastError,
+		"next_attempt_at": job.NextAttemptAt,
+		"sending":         false,
+	}})
+	if err != nil {
+		log.Printf("Error scheduling retry for delivery %s: %v", job.ID.Hex(), err)
+	}
+}
+
+// deadLetter moves a job that ran out of attempts out of the queue.
+func (rc *RestController) deadLetter(ctx context.Context, job *DeliveryJob) {
+	deadLetters, err := rc.deadLetters()
+	if err != nil {
+		log.Printf("Error storing dead letter: %v", err)
+		return
+	}
+	queue, err := rc.deliveryQueue()
+	if err != nil {
+		log.Printf("Error storing dead letter: %v", err)
+		return
+	}
+
+	rc.recordOutcome(ctx, job, outcomeFailed, job.LastError)
+
+	job.FailedAt = time.Now()
+	if _, err := deadLetters.InsertOne(ctx, job); err != nil {
+		log.Printf("Error storing dead letter %s: %v", job.ID.Hex(), err)
+		return
+	}
+	if _, err := queue.DeleteOne(ctx, bson.M{"_id": job.ID}); err != nil {
+		log.Printf("Error removing delivery %s: %v", job.ID.Hex(), err)
+	}
+}
+
+// claimDelivery leases the next job that is due, or returns nil when there is
+// none. Receivers listed in skip are left alone.
+func (rc *RestController) claimDelivery(ctx context.Context, skip bson.A) (*DeliveryJob, error) {
+	collection, err := rc.deliveryQueue()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	filter := bson.M{"next_attempt_at": bson.M{"$lte": now}}
+	if len(skip) > 0 {
+		filter["$nor"] = skip
+	}
+
+	var job DeliveryJob
+	err = collection.FindOneAndUpdate(ctx,
+		filter,
+		bson.M{"$set": bson.M{"next_attempt_at": now.Add(deliveryLease), "lease_id": primitive.NewObjectID(), "sending": false}},
+		options.FindOneAndUpdate().
+			SetSort(bson.M{"next_attempt_at": 1}).
+			SetReturnDocument(options.After),
+	).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim delivery: %w", err)
+	}
+	return &job, nil
+}
+
+// releaseDelivery gives up the lease on a job so the poller picks it up again.
+func (rc *RestController) releaseDelivery(ctx context.Context, job *DeliveryJob) {
+	collection, err := rc.deliveryQueue()
+	if err != nil {
+		log.Printf("Error releasing delivery %s: %v", job.ID.Hex(), err)
+		return
+	}
+
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": job.ID, "lease_id": job.LeaseID}, bson.M{"$set": bson.M{"next_attempt_at": time.Now()}})
+	if err != nil {
+		log.Printf("Error releasing delivery %s: %v", job.ID.Hex(), err)
+	}
+}
+
+// lookupReceiver finds the receiver a job was queued for.
+func (rc *RestController) lookupReceiver(job *DeliveryJob) *Receiver {
+	if job.Channel {
+		return rc.Channels[job.Receiver]
+	}
+	return rc.Receivers[job.Receiver]
+}
+
+// listQueuedDeliveries returns the jobs waiting to be sent, oldest first.
+func (rc *RestController) listQueuedDeliveries(ctx context.Context, filter bson.M) ([]DeliveryJob, error) {
+	collection, err := rc.deliveryQueue()
+	if err != nil {
+		return nil, err
+	}
+	return findDeliveries(ctx, collection, filter)
+}
+
+// listDeadLetters returns the jobs that ran out of attempts, oldest first.
+func (rc *RestController) listDeadLetters(ctx context.Context, filter bson.M) ([]DeliveryJob, error) {
+	collection, err := rc.deadLetters()
+	if err != nil {
+		return nil, err
+	}
+	return findDeliveries(ctx, collection, filter)
+}
+
+func findDeliveries(ctx context.Context, collection *mongo.Collection, filter bson.M) ([]DeliveryJob, error) {
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deliveries: %w", err)
+	}
+
+	results := []DeliveryJob{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode deliveries: %w", err)
+	}
+	return results, nil
+}
+
+// errStaleDeadLetter refuses a replay that would send an outdated state of
+// the alert.
+var errStaleDeadLetter = errors.New("a newer notification for the alert exists")
+
+// replayDeadLetter puts a dead letter back in the queue with a fresh set of
+// attempts and returns the new job, or nil if the dead letter does not exist.
+// Dead letters older than a queued job for the same alert and receiver, or
+// whose status the alert no longer has, are refused with errStaleDeadLetter.
+func (rc *RestController) replayDeadLetter(ctx context.Context, id primitive.ObjectID) (*DeliveryJob, error) {
+	deadLetters, err := rc.deadLetters()
+	if err != nil {
+		return nil, err
+	}
+
+	var job DeliveryJob
+	err = deadLetters.FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letter: %w", err)
+	}
+
+	if job.Alert.Fingerprint != "" {
+		newer, err := rc.listQueuedDeliveries(ctx, bson.M{
+			"receiver":          job.Receiver,
+			"channel":           job.Channel,
+			"alert.fingerprint": job.Alert.Fingerprint,
+			"created_at":        bson.M{"$gt": job.CreatedAt},
+		})
+		if err != nil {
+			return nil, err
+		}
+		record, err := rc.findAlertRecord(ctx, job.Alert.Fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		if len(newer) > 0 || (record != nil && record.Status != job.Alert.Status) {
+			return nil, errStaleDeadLetter
+		}
+	}
+
+	job.ID = primitive.NilObjectID
+	job.LeaseID = primitive.NilObjectID
+	job.Sending = false
+	job.Attempts = 0
+	job.LastError = ""
+	job.FailedAt = time.Time{}
+	job.NextAttemptAt = time.Now()
+	job.CreatedAt = time.Now()
+	if err := rc.enqueueDelivery(ctx, &job, false); err != nil {
+		return nil, err
+	}
+
+	if _, err := deadLetters.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		return nil, fmt.Errorf("failed to remove dead letter: %w", err)
+	}
+	return &job, nil
+}
+
+// deleteDeadLetter deletes a dead letter and reports whether it existed.
+func (rc *RestController) deleteDeadLetter(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	collection, err := rc.deadLetters()
+	if err != nil {
+		return false, err
+	}
+
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete dead letter: %w", err)
+	}
+	return result.DeletedCount > 0, nil
+}
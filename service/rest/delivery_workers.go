@@ -0,0 +1,165 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// DeliverySettings sizes the delivery worker pool.
+type DeliverySettings struct {
+	// Workers caps the number of deliveries in flight across all receivers.
+	Workers int
+	// QueueSize is how many jobs each receiver buffers before webhooks are
+	// refused with 503.
+	QueueSize int
+	// PollInterval is how often the queue is checked for due retries.
+	PollInterval time.Duration
+}
+
+// laneKey names the job buffer of a receiver. Channel receivers share names
+// with routing receivers, so they get their own prefix.
+func laneKey(channel bool, name string) string {
+	if channel {
+		return "channel:" + name
+	}
+	return "receiver:" + name
+}
+
+// StartDeliveryWorkers starts Concurrency workers per receiver, limited to
+// settings.Workers sends at a time overall, and the poller that feeds them
+// retries and jobs left over from a restart. They run until Close is called.
+// Each worker has its own buffer and all jobs of an alert go to the same
+// worker, so a resolution is never sent before its firing notification.
+func (rc *RestController) StartDeliveryWorkers(settings DeliverySettings) {
+	ctx, cancel := context.WithCancel(context.Background())
+	rc.stopWorker = cancel
+	rc.workerSlots = make(chan struct{}, settings.Workers)
+	rc.lanes = make(map[string][]chan *DeliveryJob)
+	rc.queueSize = settings.QueueSize
+
+	start := func(channel bool, receivers map[string]*Receiver) {
+		for name, receiver := range receivers {
+			lanes := make([]chan *DeliveryJob, max(receiver.Concurrency, 1))
+			for i := range lanes {
+				lanes[i] = make(chan *DeliveryJob, settings.QueueSize)
+				rc.workers.Add(1)
+				go rc.runLane(ctx, receiver, lanes[i])
+			}
+			rc.lanes[laneKey(channel, name)] = lanes
+		}
+	}
+	start(false, rc.Receivers)
+	start(true, rc.Channels)
+
+	rc.workers.Add(1)
+	go rc.pollDeliveries(ctx, settings.PollInterval)
+}
+
+// runLane sends the jobs of one worker. Shutdown cancels the send in progress;
+// it and the jobs still buffered stay in the queue and are sent after the next
+// start.
+func (rc *RestController) runLane(ctx context.Context, receiver *Receiver, lane chan *DeliveryJob) {
+	defer rc.workers.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-lane:
+			select {
+			case rc.workerSlots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			attemptCtx, cancel := context.WithTimeout(ctx, deliveryAttemptTimeout)
+			rc.attemptDelivery(attemptCtx, receiver, job)
+			cancel()
+			<-rc.workerSlots
+		}
+	}
+}
+
+// push hands a claimed job to the worker of its alert and reports false when
+// that worker's buffer is full.
+func (rc *RestController) push(job *DeliveryJob) bool {
+	lanes, ok := rc.lanes[laneKey(job.Channel, job.Receiver)]
+	if !ok {
+		return false
+	}
+
+	key := job.Alert.Fingerprint
+	if key == "" {
+		key = job.ID.Hex()
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	lane := lanes[hash.Sum32()%uint32(len(lanes))]
+
+	select {
+	case lane <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// laneFull reports whether the receiver already buffers QueueSize jobs.
+func (rc *RestController) laneFull(channel bool, name string) bool {
+	lanes, ok := rc.lanes[laneKey(channel, name)]
+	if !ok {
+		return false
+	}
+	buffered := 0
+	for _, lane := range lanes {
+		buffered += len(lane)
+	}
+	return buffered >= rc.queueSize
+}
+
+func (rc *RestController) pollDeliveries(ctx context.Context, interval time.Duration) {
+	defer rc.workers.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		rc.queueDueDeliveries(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// queueDueDeliveries claims the jobs that are due and hands them to their
+// receivers, skipping receivers whose buffer is full until the next run.
+func (rc *RestController) queueDueDeliveries(ctx context.Context) {
+	skip := bson.A{}
+	for ctx.Err() == nil {
+		job, err := rc.claimDelivery(ctx, skip)
+		if err != nil {
+			log.Printf("Error processing delivery queue: %v", err)
+			return
+		}
+		if job == nil {
+			return
+		}
+
+		if rc.lookupReceiver(job) == nil {
+			job.Attempts++
+			job.LastError = fmt.Sprintf("unknown receiver %s", job.Receiver)
+			log.Printf("Dropping delivery %s: %s", job.ID.Hex(), job.LastError)
+			rc.deadLetter(ctx, job)
+			continue
+		}
+
+		if !rc.push(job) {
+			rc.releaseDelivery(ctx, job)
+			skip = append(skip, bson.M{"receiver": job.Receiver, "channel": job.Channel})
+		}
+	}
+}
@@ -0,0 +1,567 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"webhook-server/service/helper"
+	"webhook-server/service/model"
+	"webhook-server/service/templates"
+)
+
+const (
+	discordSuppressMenuPrefix  = "suppress:"
+	discordSuppressModalPrefix = "suppress_modal:"
+	discordUnmutePrefix        = "unmute:"
+	discordCustomDuration      = "custom"
+	discordCustomIDLimit       = 100
+)
+
+var discordSuppressOptions = buildDiscordSuppressOptions()
+
+// buildDiscordSuppressOptions offers the shared duration choices and a custom
+// duration entered in a modal.
+func buildDiscordSuppressOptions() []discordgo.SelectMenuOption {
+	options := make([]discordgo.SelectMenuOption, 0, len(suppressChoices)+1)
+	for _, choice := range suppressChoices {
+		options = append(options, discordgo.SelectMenuOption{Label: choice.Label, Value: choice.Value})
+	}
+	return append(options, discordgo.SelectMenuOption{Label: "Tùy chỉnh…", Value: discordCustomDuration, Description: "Nhập thời gian và lý do"})
+}
+
+// buildDiscordSuppressComponents returns the suppress duration menu for a
+// firing message, or nil when the alert key does not fit in a custom ID.
+func buildDiscordSuppressComponents(alert model.Alert) []discordgo.MessageComponent {
+	if len(alertKey(discordSuppressModalPrefix, alert)) > discordCustomIDLimit {
+		log.Printf("Custom ID too long for alert %s, sending without suppress menu", alert.Fingerprint)
+		return nil
+	}
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					MenuType:    discordgo.StringSelectMenu,
+					CustomID:    alertKey(discordSuppressMenuPrefix, alert),
+					Placeholder: "Tắt thông báo trong...",
+					Options:     discordSuppressOptions,
+				},
+			},
+		},
+	}
+}
+
+// Discord limits button labels to 80 characters
+const discordButtonLabelLimit = 80
+
+// buildDiscordAckComponents returns the acknowledge button, disabled and
+// naming the owner once someone took the alert. Alerts without a fingerprint
+// can't be acknowledged.
+func buildDiscordAckComponents(alert model.Alert, owner string) []discordgo.MessageComponent {
+	if alert.Fingerprint == "" {
+		return nil
+	}
+
+	button := discordgo.Button{
+		Label:    "Nhận xử lý",
+		Style:    discordgo.SuccessButton,
+		CustomID: ackKeyPrefix + alert.Fingerprint,
+	}
+	if owner != "" {
+		button.Label = truncate("Đã nhận bởi "+owner, discordButtonLabelLimit)
+		button.Style = discordgo.SecondaryButton
+		button.Disabled = true
+	}
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{button}},
+	}
+}
+
+// Discord rejects embeds beyond these limits
+const (
+	discordEmbedTitleLimit       = 256
+	discordEmbedDescriptionLimit = 4096
+	discordEmbedFieldLimit       = 25
+	discordEmbedFieldNameLimit   = 256
+	discordEmbedFieldValueLimit  = 1024
+)
+
+var discordSeverityColors = map[string]int{
+	"critical": 0xd32f2f,
+	"error":    0xd32f2f,
+	"warning":  0xf57c00,
+	"info":     0x1976d2,
+}
+
+const (
+	discordFiringColor   = 0xd32f2f
+	discordResolvedColor = 0x388e3c
+)
+
+// buildDiscordMessage returns the Discord message for the rendered alert: the
+// embed, the suppress menu and acknowledge button when firing, and the links.
+func buildDiscordMessage(alert model.Alert, message *templates.Message) *discordgo.MessageSend {
+	components := []discordgo.MessageComponent{}
+	if alert.Status == "firing" {
+		components = append(components, buildDiscordSuppressComponents(alert)...)
+		components = append(components, buildDiscordAckComponents(alert, "")...)
+	}
+	return &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{buildDiscordEmbed(alert, message)},
+		Components: append(components, buildDiscordLinkButtons(alert)...),
+	}
+}
+
+// buildDiscordEmbed lays out the rendered message as an embed colored by
+// severity, with a field for each label and annotation.
+func buildDiscordEmbed(alert model.Alert, message *templates.Message) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       truncate(message.Title, discordEmbedTitleLimit),
+		Description: truncate(message.Body, discordEmbedDescriptionLimit),
+		Color:       discordFiringColor,
+	}
+	if color, ok := discordSeverityColors[strings.ToLower(alert.Labels["severity"])]; ok {
+		embed.Color = color
+	}
+	if alert.Status == "resolved" {
+		embed.Color = discordResolvedColor
+	}
+
+	var fields []*discordgo.MessageEmbedField
+	for _, label := range helper.SortedLabels(alert.Labels) {
+		fields = append(fields, discordField(label.Name, label.Value, true))
+	}
+	// The summary is the description and the runbook a link button
+	annotations := helper.ExcludeLabels(alert.Annotations, "summary", "runbook_url")
+	for _, annotation := range helper.SortedLabels(annotations) {
+		if annotation.Name == "values" || strings.HasPrefix(annotation.Name, "value_") {
+			continue
+		}
+		fields = append(fields, discordField(annotation.Name, annotation.Value, false))
+	}
+
+	if !alert.StartsAt.IsZero() {
+		fields = append(fields, discordField("Bắt đầu", discordTimestamp(alert.StartsAt), true))
+		embed.Timestamp = alert.StartsAt.Format(time.RFC3339)
+	}
+	if alert.Status == "resolved" && !alert.EndsAt.IsZero() {
+		fields = append(fields, discordField("Kết thúc", discordTimestamp(alert.EndsAt), true))
+		embed.Timestamp = alert.EndsAt.Format(time.RFC3339)
+	}
+
+	if len(fields) > discordEmbedFieldLimit {
+		fields = fields[:discordEmbedFieldLimit]
+	}
+	embed.Fields = fields
+	return embed
+}
+
+func discordField(name, value string, inline bool) *discordgo.MessageEmbedField {
+	if value == "" {
+		value = "-"
+	}
+	return &discordgo.MessageEmbedField{
+		Name:   truncate(name, discordEmbedFieldNameLimit),
+		Value:  truncate(value, discordEmbedFieldValueLimit),
+		Inline: inline,
+	}
+}
+
+// discordTimestamp shows the time in each reader's own timezone.
+func discordTimestamp(t time.Time) string {
+	return fmt.Sprintf("<t:%d:f>", t.Unix())
+}
+
+// buildDiscordLinkButtons returns a row of buttons for the alert's dashboard,
+// panel, source and runbook links, or nil when it has none.
+func buildDiscordLinkButtons(alert model.Alert) []discordgo.MessageComponent {
+	links := []struct{ label, url string }{
+		{"Dashboard", alert.DashboardURL},
+		{"Panel", alert.PanelURL},
+		{"Nguồn", alert.GeneratorURL},
+		{"Runbook", alert.Annotations["runbook_url"]},
+	}
+
+	var buttons []discordgo.MessageComponent
+	for _, link := range links {
+		u, err := url.Parse(link.url)
+		if link.url == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		buttons = append(buttons, discordgo.Button{
+			Label: link.label,
+			Style: discordgo.LinkButton,
+			URL:   link.url,
+		})
+	}
+
+	if len(buttons) == 0 {
+		return nil
+	}
+	return []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}}
+}
+
+// discordLinkRows returns the rows of link buttons of a sent message, so they
+// survive when its other components are replaced.
+func discordLinkRows(components []discordgo.MessageComponent) []discordgo.MessageComponent {
+	var rows []discordgo.MessageComponent
+	for _, component := range components {
+		row, ok := component.(*discordgo.ActionsRow)
+		if !ok || len(row.Components) == 0 {
+			continue
+		}
+		links := true
+		for _, item := range row.Components {
+			if button, ok := item.(*discordgo.Button); !ok || button.Style != discordgo.LinkButton {
+				links = false
+			}
+		}
+		if links {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// Discord limits thread names to 100 characters
+const discordThreadNameLimit = 100
+
+// discordThreadName names an incident thread after the alert and instance.
+func discordThreadName(alert model.Alert) string {
+	var parts []string
+	for _, value := range []string{alert.Labels["alertname"], alert.Labels["instance"]} {
+		if value != "" {
+			parts = append(parts, value)
+		}
+	}
+	name := strings.Join(parts, " · ")
+	if name == "" {
+		name = alert.Annotations["summary"]
+	}
+	if name == "" {
+		name = alert.Fingerprint
+	}
+	return truncate(name, discordThreadNameLimit)
+}
+
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+func (rc *RestController) handleDiscordComponent(w http.ResponseWriter, interaction *discordgo.Interaction) {
+	data := interaction.MessageComponentData()
+
+	switch {
+	case strings.HasPrefix(data.CustomID, discordSuppressMenuPrefix):
+		if !authorizeDiscord(w, interaction, discordActionSuppress) {
+			return
+		}
+		if len(data.Values) == 0 {
+			log.Printf("Invalid custom ID: %s", data.CustomID)
+			respondDiscordEphemeral(w, "Vui lòng chọn thời gian tắt thông báo.")
+			return
+		}
+
+		if data.Values[0] == discordCustomDuration {
+			key := discordSuppressModalPrefix + strings.TrimPrefix(data.CustomID, discordSuppressMenuPrefix)
+			writeDiscordResponse(w, buildDiscordSuppressModal(key))
+			return
+		}
+
+		duration, err := helper.ParseDuration(data.Values[0])
+		if err != nil {
+			log.Printf("Invalid suppress duration %s: %v", data.Values[0], err)
+			respondDiscordEphemeral(w, fmt.Sprintf("Thời gian **%s** không hợp lệ.", data.Values[0]))
+			return
+		}
+		rc.suppressFromDiscord(w, interaction, discordSuppressMenuPrefix, data.CustomID, duration, "")
+
+	case strings.HasPrefix(data.CustomID, ackKeyPrefix):
+		if !authorizeDiscord(w, interaction, discordActionAck) {
+			return
+		}
+		rc.acknowledgeFromDiscord(w, interaction, data.CustomID)
+
+	case strings.HasPrefix(data.CustomID, discordUnmutePrefix):
+		if !authorizeDiscord(w, interaction, discordActionUnsuppress) {
+			return
+		}
+		rc.unsuppressFromDiscord(w, interaction, data.CustomID)
+
+	case strings.HasPrefix(data.CustomID, suppressKeyPrefix):
+		// Buttons on messages sent before the duration menu existed
+		if !authorizeDiscord(w, interaction, discordActionSuppress) {
+			return
+		}
+		rc.suppressFromDiscord(w, interaction, suppressKeyPrefix, data.CustomID, suppressDuration, "")
+
+	default:
+		log.Printf("Invalid custom ID: %s", data.CustomID)
+		respondDiscordEphemeral(w, "Thao tác không được hỗ trợ.")
+	}
+}
+
+func (rc *RestController) handleDiscordModal(w http.ResponseWriter, interaction *discordgo.Interaction) {
+	data := interaction.ModalSubmitData()
+	if !strings.HasPrefix(data.CustomID, discordSuppressModalPrefix) {
+		log.Printf("Invalid modal ID: %s", data.CustomID)
+		respondDiscordEphemeral(w, "Thao tác không được hỗ trợ.")
+		return
+	}
+	if !authorizeDiscord(w, interaction, discordActionSuppress) {
+		return
+	}
+
+	values := discordModalValues(data)
+	duration, err := helper.ParseDuration(values["duration"])
+	if err != nil || duration <= 0 || duration > maxSuppressDuration {
+		respondDiscordEphemeral(w, fmt.Sprintf("Thời gian **%s** không hợp lệ. Ví dụ: 30m, 6h, 2d, 1w (tối đa %s).",
+			values["duration"], helper.HumanizeDuration(maxSuppressDuration)))
+		return
+	}
+
+	rc.suppressFromDiscord(w, interaction, discordSuppressModalPrefix, data.CustomID, duration, strings.TrimSpace(values["reason"]))
+}
+
+// suppressFromDiscord silences the alert behind the component, replaces the
+// menu on the alert message and confirms to the user with an ephemeral reply.
+func (rc *RestController) suppressFromDiscord(w http.ResponseWriter, interaction *discordgo.Interaction, prefix, key string, duration time.Duration, reason string) {
+	target, err := rc.resolveAlertKey(context.TODO(), prefix, key)
+	if err != nil {
+		log.Printf("Error resolving alert for %s: %v", key, err)
+		respondDiscordEphemeral(w, "Không tìm thấy cảnh báo để tắt thông báo.")
+		return
+	}
+
+	username := discordUsername(interaction)
+	summary := reason
+	if summary == "" {
+		summary = fmt.Sprintf("Suppressed via Discord by %s", username)
+	}
+
+	// Suppress alert in MongoDB
+	suppressedUntil := time.Now().Add(duration)
+	if err := rc.suppressTarget(context.TODO(), target, suppressedUntil, username, summary); err != nil {
+		log.Printf("Error suppressing alert: %v", err)
+		respondDiscordEphemeral(w, "Không thể tắt thông báo, vui lòng thử lại.")
+		return
+	}
+
+	// Update original message
+	humanDuration := helper.HumanizeDuration(duration)
+	updatedMessage := fmt.Sprintf("Thông báo %s sẽ được bỏ qua trong %s bởi %s", target.describe(discordBold), humanDuration, username)
+	if reason != "" {
+		updatedMessage += fmt.Sprintf("\n> 📝 **Lý do:** %s", reason)
+	}
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    fmt.Sprintf("Đã tắt đến %s", suppressedUntil.Format("02/01 15:04")),
+					Style:    discordgo.PrimaryButton,
+					CustomID: suppressKeyPrefix + strings.TrimPrefix(key, prefix),
+					Disabled: true,
+				},
+				discordgo.Button{
+					Label:    "Bật lại thông báo",
+					Style:    discordgo.SecondaryButton,
+					CustomID: discordUnmutePrefix + strings.TrimPrefix(key, prefix),
+				},
+			},
+		},
+	}
+	// Keep the ack button, with the owner if there is one
+	alert := model.Alert{Fingerprint: target.Fingerprint, Labels: target.Labels}
+	components = append(components, buildDiscordAckComponents(alert, rc.alertOwner(context.TODO(), alert.Fingerprint))...)
+	if interaction.Message != nil {
+		components = append(components, discordLinkRows(interaction.Message.Components)...)
+		if err := rc.Discord.UpdateMessage(interaction.ChannelID, interaction.Message.ID, updatedMessage, components); err != nil {
+			log.Printf("Error updating message: %v", err)
+		}
+		rc.postToDiscordThread(context.TODO(), interaction.ChannelID, interaction.Message.ID, "🔕 "+updatedMessage)
+	}
+
+	respondDiscordEphemeral(w, fmt.Sprintf("Thông báo %s sẽ được bỏ qua trong %s.", target.describe(discordBold), humanDuration))
+}
+
+// unsuppressFromDiscord deletes the silence behind the unmute button, brings
+// back the suppress menu and records who did it on the message.
+func (rc *RestController) unsuppressFromDiscord(w http.ResponseWriter, interaction *discordgo.Interaction, customID string) {
+	ctx := context.TODO()
+	target, err := rc.resolveAlertKey(ctx, discordUnmutePrefix, customID)
+	if err != nil {
+		log.Printf("Error resolving alert for %s: %v", customID, err)
+		respondDiscordEphemeral(w, "Không tìm thấy cảnh báo để bật lại thông báo.")
+		return
+	}
+
+	deleted, err := rc.unsuppressTarget(ctx, target)
+	if err != nil {
+		log.Printf("Error removing suppression: %v", err)
+		respondDiscordEphemeral(w, "Không thể bật lại thông báo, vui lòng thử lại.")
+		return
+	}
+	username := discordUsername(interaction)
+	log.Printf("Removed %d suppressions for %s via Discord by %s", deleted, target.Key, username)
+
+	// Restore the buttons of a firing message, with the owner if there is one
+	alert := model.Alert{Fingerprint: target.Fingerprint, Labels: target.Labels}
+	owner := rc.alertOwner(ctx, alert.Fingerprint)
+	updatedMessage := fmt.Sprintf("🔔 Thông báo %s đã được bật lại bởi %s", target.describe(discordBold), username)
+	if interaction.Message != nil {
+		components := append(buildDiscordSuppressComponents(alert), buildDiscordAckComponents(alert, owner)...)
+		components = append(components, discordLinkRows(interaction.Message.Components)...)
+		if err := rc.Discord.UpdateMessage(interaction.ChannelID, interaction.Message.ID, updatedMessage, components); err != nil {
+			log.Printf("Error updating message: %v", err)
+		}
+		rc.postToDiscordThread(ctx, interaction.ChannelID, interaction.Message.ID, updatedMessage)
+	}
+
+	if deleted == 0 {
+		respondDiscordEphemeral(w, fmt.Sprintf("Thông báo %s không còn bị tắt.", target.describe(discordBold)))
+		return
+	}
+	respondDiscordEphemeral(w, fmt.Sprintf("Đã bật lại thông báo %s.", target.describe(discordBold)))
+}
+
+// acknowledgeFromDiscord makes the user the owner of the alert, shows them on
+// the alert message and in its thread, and confirms with an ephemeral reply.
+func (rc *RestController) acknowledgeFromDiscord(w http.ResponseWriter, interaction *discordgo.Interaction, customID string) {
+	ctx := context.TODO()
+	fingerprint, err := ackFingerprint(customID)
+	var record *AlertRecord
+	if err == nil {
+		record, err = rc.findAlertRecord(ctx, fingerprint)
+	}
+	if err != nil || record == nil {
+		log.Printf("Error resolving alert for %s: %v", customID, err)
+		respondDiscordEphemeral(w, "Không tìm thấy cảnh báo để nhận xử lý.")
+		return
+	}
+	if record.Status == "resolved" {
+		respondDiscordEphemeral(w, "Cảnh báo đã được giải quyết.")
+		return
+	}
+
+	username := discordUsername(interaction)
+	ack, err := rc.acknowledgeAlert(ctx, fingerprint, username)
+	if err != nil {
+		log.Printf("Error acknowledging alert: %v", err)
+		respondDiscordEphemeral(w, "Không thể nhận xử lý cảnh báo, vui lòng thử lại.")
+		return
+	}
+
+	// Keep the suppress menu and links, show the owner on the button
+	alert := model.Alert{Fingerprint: record.Fingerprint, Labels: record.Labels, Status: record.Status}
+	updatedMessage := fmt.Sprintf("👤 %s đã nhận xử lý lúc %s", username, discordTimestamp(ack.AcknowledgedAt))
+	if interaction.Message != nil {
+		components := append(buildDiscordSuppressComponents(alert), buildDiscordAckComponents(alert, username)...)
+		components = append(components, discordLinkRows(interaction.Message.Components)...)
+		if err := rc.Discord.UpdateMessage(interaction.ChannelID, interaction.Message.ID, updatedMessage, components); err != nil {
+			log.Printf("Error updating message: %v", err)
+		}
+		rc.postToDiscordThread(ctx, interaction.ChannelID, interaction.Message.ID, updatedMessage)
+	}
+
+	respondDiscordEphemeral(w, "Bạn đã nhận xử lý cảnh báo. Thông báo lặp lại sẽ được bỏ qua cho đến khi cảnh báo được giải quyết.")
+}
+
+// postToDiscordThread posts a note into the incident thread of an alert
+// message, if it has one.
+func (rc *RestController) postToDiscordThread(ctx context.Context, channelID, messageID, content string) {
+	record, err := rc.findDiscordMessageFor(ctx, channelID, messageID)
+	if err != nil {
+		log.Printf("Error finding Discord message: %v", err)
+		return
+	}
+	if record == nil || record.ThreadID == "" {
+		return
+	}
+	if _, err := rc.Discord.SendThreadMessage(record.ThreadID, content); err != nil {
+		log.Printf("Error posting to Discord thread: %v", err)
+	}
+}
+
+func buildDiscordSuppressModal(customID string) *discordgo.InteractionResponse {
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: customID,
+			Title:    "Tắt thông báo",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "duration",
+							Label:       "Thời gian",
+							Style:       discordgo.TextInputShort,
+							Placeholder: "Ví dụ: 30m, 6h, 2d, 1w",
+							Required:    true,
+							MaxLength:   20,
+						},
+					},
+				},
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:  "reason",
+							Label:     "Lý do",
+							Style:     discordgo.TextInputParagraph,
+							Required:  false,
+							MaxLength: 300,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// discordModalValues collects the submitted text inputs by custom ID.
+func discordModalValues(data discordgo.ModalSubmitInteractionData) map[string]string {
+	values := make(map[string]string)
+	for _, component := range data.Components {
+		row, ok := component.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, item := range row.Components {
+			if input, ok := item.(*discordgo.TextInput); ok {
+				values[input.CustomID] = input.Value
+			}
+		}
+	}
+	return values
+}
+
+func discordBold(text string) string {
+	return "**" + text + "**"
+}
+
+func respondDiscordEphemeral(w http.ResponseWriter, content string) {
+	writeDiscordResponse(w, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+func writeDiscordResponse(w http.ResponseWriter, response *discordgo.InteractionResponse) {
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error writing interaction response: %v", err)
+	}
+}
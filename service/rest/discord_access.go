@@ -0,0 +1,90 @@
+package rest
+
+import (
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+
+	"webhook-server/service/config"
+)
+
+// Discord actions that can be limited to roles or users
+const (
+	discordActionSuppress   = "suppress"
+	discordActionUnsuppress = "unsuppress"
+	discordActionAck        = "ack"
+)
+
+var discordDeniedMessages = map[string]string{
+	discordActionSuppress:   "Bạn không có quyền tắt thông báo.",
+	discordActionUnsuppress: "Bạn không có quyền bật lại thông báo.",
+	discordActionAck:        "Bạn không có quyền nhận xử lý cảnh báo.",
+}
+
+// discordUser returns who triggered the interaction: the member in a server,
+// the user in direct messages.
+func discordUser(interaction *discordgo.Interaction) *discordgo.User {
+	if interaction.Member != nil && interaction.Member.User != nil {
+		return interaction.Member.User
+	}
+	if interaction.User != nil {
+		return interaction.User
+	}
+	return &discordgo.User{}
+}
+
+func discordUsername(interaction *discordgo.Interaction) string {
+	return discordUser(interaction).Username
+}
+
+// discordAllowed reports whether the user may take the action. An action
+// without allowed roles or users is open to everyone; roles only count in a
+// server, where the member is known.
+func discordAllowed(interaction *discordgo.Interaction, roles, users []string) bool {
+	if len(roles) == 0 && len(users) == 0 {
+		return true
+	}
+	if slices.Contains(users, discordUser(interaction).ID) {
+		return true
+	}
+	if interaction.Member != nil {
+		for _, role := range interaction.Member.Roles {
+			if slices.Contains(roles, role) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// authorizeDiscord checks the action against DISCORD_<ACTION>_ROLES and
+// DISCORD_<ACTION>_USERS, telling the user with an ephemeral reply when it is
+// not allowed.
+func authorizeDiscord(w http.ResponseWriter, interaction *discordgo.Interaction, action string) bool {
+	config, err := config.GetConfig()
+	if err != nil {
+		log.Printf("Error loading config: %v", err)
+		respondDiscordEphemeral(w, "Đã xảy ra lỗi, vui lòng thử lại.")
+		return false
+	}
+
+	var roles, users []string
+	switch action {
+	case discordActionSuppress:
+		roles, users = config.DiscordSuppressRoles, config.DiscordSuppressUsers
+	case discordActionUnsuppress:
+		roles, users = config.DiscordUnsuppressRoles, config.DiscordUnsuppressUsers
+	case discordActionAck:
+		roles, users = config.DiscordAckRoles, config.DiscordAckUsers
+	}
+
+	if discordAllowed(interaction, roles, users) {
+		return true
+	}
+	user := discordUser(interaction)
+	log.Printf("Discord user %s (%s) is not allowed to %s", user.Username, user.ID, action)
+	respondDiscordEphemeral(w, discordDeniedMessages[action])
+	return false
+}
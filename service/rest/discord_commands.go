@@ -0,0 +1,327 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"webhook-server/service/helper"
+	"webhook-server/service/matcher"
+)
+
+// Discord shows at most 25 autocomplete choices
+const discordChoiceLimit = 25
+
+var discordGuildOnly = []discordgo.InteractionContextType{discordgo.InteractionContextGuild}
+
+var discordCommands = []*discordgo.ApplicationCommand{
+	{
+		Name:        "alerts",
+		Description: "Xem cảnh báo",
+		Contexts:    &discordGuildOnly,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "active",
+				Description: "Các cảnh báo đang hoạt động",
+			},
+		},
+	},
+	{
+		Name:        "silence",
+		Description: "Quản lý tắt thông báo",
+		Contexts:    &discordGuildOnly,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "Các thông báo đang bị tắt",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Tắt thông báo cho một node và device",
+				Options: []*discordgo.ApplicationCommandOption{
+					{Type: discordgo.ApplicationCommandOptionString, Name: "instance", Description: "Node, ví dụ 10.0.0.1:9100", Required: true},
+					{Type: discordgo.ApplicationCommandOptionString, Name: "device", Description: "Device, ví dụ sda", Required: true},
+					{Type: discordgo.ApplicationCommandOptionString, Name: "duration", Description: "Thời gian, ví dụ 30m, 6h, 2d, 1w", Required: true},
+					{Type: discordgo.ApplicationCommandOptionString, Name: "reason", Description: "Lý do"},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Bật lại thông báo",
+				Options: []*discordgo.ApplicationCommandOption{
+					{Type: discordgo.ApplicationCommandOptionString, Name: "id", Description: "Tắt thông báo cần xóa", Required: true, Autocomplete: true},
+				},
+			},
+		},
+	},
+}
+
+// RegisterDiscordCommands registers the slash commands for the application,
+// in one guild when guildID is set (available immediately) or globally.
+func RegisterDiscordCommands(session *discordgo.Session, applicationID, guildID string) error {
+	if _, err := session.ApplicationCommandBulkOverwrite(applicationID, guildID, discordCommands); err != nil {
+		return fmt.Errorf("failed to register Discord commands: %w", err)
+	}
+	return nil
+}
+
+func (rc *RestController) handleDiscordCommand(w http.ResponseWriter, interaction *discordgo.Interaction) {
+	data := interaction.ApplicationCommandData()
+	if len(data.Options) == 0 {
+		log.Printf("Discord command %s without subcommand", data.Name)
+		return
+	}
+	subcommand := data.Options[0]
+
+	ctx := context.TODO()
+	switch data.Name + " " + subcommand.Name {
+	case "alerts active":
+		rc.listActiveAlertsCommand(ctx, w)
+	case "silence list":
+		rc.listSilencesCommand(ctx, w)
+	case "silence add":
+		if !authorizeDiscord(w, interaction, discordActionSuppress) {
+			return
+		}
+		rc.addSilenceCommand(ctx, w, interaction, discordOptionValues(subcommand.Options))
+	case "silence remove":
+		if !authorizeDiscord(w, interaction, discordActionUnsuppress) {
+			return
+		}
+		rc.removeSilenceCommand(ctx, w, discordOptionValues(subcommand.Options)["id"], discordUsername(interaction))
+	default:
+		log.Printf("Unknown Discord command: %s %s", data.Name, subcommand.Name)
+		respondDiscordEphemeral(w, "Lệnh không được hỗ trợ.")
+	}
+}
+
+// handleDiscordAutocomplete suggests active silences for /silence remove.
+func (rc *RestController) handleDiscordAutocomplete(w http.ResponseWriter, interaction *discordgo.Interaction) {
+	data := interaction.ApplicationCommandData()
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+
+	if data.Name == "silence" && len(data.Options) > 0 && data.Options[0].Name == "remove" {
+		query := strings.ToLower(discordOptionValues(data.Options[0].Options)["id"])
+		suppressions, err := rc.listSuppressions(context.TODO(), bson.M{"suppressed_until": bson.M{"$gt": time.Now()}})
+		if err != nil {
+			log.Printf("Error listing suppressions: %v", err)
+		}
+		for _, suppression := range suppressions {
+			name := suppression.LabelMatchers().String()
+			if query != "" && !strings.Contains(strings.ToLower(name), query) && !strings.HasPrefix(suppression.ID.Hex(), query) {
+				continue
+			}
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+				Name:  truncate(name, 100),
+				Value: suppression.ID.Hex(),
+			})
+			if len(choices) == discordChoiceLimit {
+				break
+			}
+		}
+	}
+
+	writeDiscordResponse(w, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: choices},
+	})
+}
+
+// listActiveAlertsCommand shows the firing alerts with their owner, marking
+// the muted ones.
+func (rc *RestController) listActiveAlertsCommand(ctx context.Context, w http.ResponseWriter) {
+	alerts, err := rc.listAlertRecords(ctx, bson.M{"status": "firing"})
+	if err != nil {
+		log.Printf("Error listing alerts: %v", err)
+		respondDiscordEphemeral(w, "Không thể lấy danh sách cảnh báo, vui lòng thử lại.")
+		return
+	}
+	now := time.Now()
+	suppressions, err := rc.listSuppressions(ctx, bson.M{"suppressed_until": bson.M{"$gt": now}})
+	if err != nil {
+		log.Printf("Error listing suppressions: %v", err)
+	}
+	acks, err := rc.listAcknowledgements(ctx)
+	if err != nil {
+		log.Printf("Error listing acknowledgements: %v", err)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("Cảnh báo đang hoạt động (%d)", len(alerts)),
+		Color: discordFiringColor,
+	}
+	if len(alerts) == 0 {
+		embed.Description = "Không có cảnh báo nào."
+		embed.Color = discordResolvedColor
+	}
+	for _, alert := range alerts {
+		if len(embed.Fields) == discordEmbedFieldLimit {
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("và %d cảnh báo khác", len(alerts)-discordEmbedFieldLimit)}
+			break
+		}
+
+		name := alert.Labels["alertname"]
+		if name == "" {
+			name = alert.Fingerprint
+		}
+		if instance := alert.Labels["instance"]; instance != "" {
+			name += " · " + instance
+		}
+
+		var lines []string
+		if summary := alert.Annotations["summary"]; summary != "" {
+			lines = append(lines, summary)
+		}
+		if !alert.StartsAt.IsZero() {
+			lines = append(lines, fmt.Sprintf("Bắt đầu <t:%d:R>", alert.StartsAt.Unix()))
+		}
+		if ack, ok := acks[alert.Fingerprint]; ok {
+			lines = append(lines, fmt.Sprintf("👤 %s nhận xử lý %s", ack.AcknowledgedBy, discordTimestamp(ack.AcknowledgedAt)))
+		}
+		if suppression := matchSuppression(suppressions, alert.Labels, now); suppression != nil {
+			lines = append(lines, fmt.Sprintf("🔕 Tắt đến %s", discordTimestamp(suppression.SuppressedUntil)))
+		}
+		embed.Fields = append(embed.Fields, discordField(name, strings.Join(lines, "\n"), false))
+	}
+
+	respondDiscordEphemeralEmbed(w, embed)
+}
+
+// listSilencesCommand shows the active silences with their IDs.
+func (rc *RestController) listSilencesCommand(ctx context.Context, w http.ResponseWriter) {
+	suppressions, err := rc.listSuppressions(ctx, bson.M{"suppressed_until": bson.M{"$gt": time.Now()}})
+	if err != nil {
+		log.Printf("Error listing suppressions: %v", err)
+		respondDiscordEphemeral(w, "Không thể lấy danh sách tắt thông báo, vui lòng thử lại.")
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("Thông báo đang bị tắt (%d)", len(suppressions)),
+		Color: discordSeverityColors["info"],
+	}
+	if len(suppressions) == 0 {
+		embed.Description = "Không có thông báo nào bị tắt."
+	}
+	for _, suppression := range suppressions {
+		if len(embed.Fields) == discordEmbedFieldLimit {
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("và %d mục khác", len(suppressions)-discordEmbedFieldLimit)}
+			break
+		}
+
+		lines := []string{fmt.Sprintf("Đến %s", discordTimestamp(suppression.SuppressedUntil))}
+		if suppression.CreatedBy != "" {
+			lines[0] += " bởi " + suppression.CreatedBy
+		}
+		if suppression.AlertSummary != "" {
+			lines = append(lines, suppression.AlertSummary)
+		}
+		lines = append(lines, fmt.Sprintf("ID: `%s`", suppression.ID.Hex()))
+		embed.Fields = append(embed.Fields, discordField(suppression.LabelMatchers().String(), strings.Join(lines, "\n"), false))
+	}
+
+	respondDiscordEphemeralEmbed(w, embed)
+}
+
+// addSilenceCommand mutes a node and device, extending the silence when one
+// for the same node and device is already active.
+func (rc *RestController) addSilenceCommand(ctx context.Context, w http.ResponseWriter, interaction *discordgo.Interaction, options map[string]string) {
+	duration, err := helper.ParseDuration(options["duration"])
+	if err != nil || duration <= 0 || duration > maxSuppressDuration {
+		respondDiscordEphemeral(w, fmt.Sprintf("Thời gian **%s** không hợp lệ. Ví dụ: 30m, 6h, 2d, 1w (tối đa %s).",
+			options["duration"], helper.HumanizeDuration(maxSuppressDuration)))
+		return
+	}
+
+	labels := map[string]string{"instance": options["instance"], "device": options["device"]}
+	target := &suppressTarget{
+		Labels: labels,
+		Matchers: matcher.Matchers{
+			{Name: "instance", Value: labels["instance"], IsEqual: true},
+			{Name: "device", Value: labels["device"], IsEqual: true},
+		},
+	}
+
+	username := discordUsername(interaction)
+	reason := strings.TrimSpace(options["reason"])
+	summary := reason
+	if summary == "" {
+		summary = fmt.Sprintf("Suppressed via Discord by %s", username)
+	}
+
+	until := time.Now().Add(duration)
+	if err := rc.suppressTarget(ctx, target, until, username, summary); err != nil {
+		log.Printf("Error suppressing alert: %v", err)
+		respondDiscordEphemeral(w, "Không thể tắt thông báo, vui lòng thử lại.")
+		return
+	}
+
+	log.Printf("Suppressed %s until %v via Discord command by %s", target.Matchers, until, username)
+	respondDiscordEphemeralEmbed(w, &discordgo.MessageEmbed{
+		Title:       "Đã tắt thông báo",
+		Description: fmt.Sprintf("Thông báo %s sẽ được bỏ qua đến %s.", target.describe(discordBold), discordTimestamp(until)),
+		Color:       discordSeverityColors["info"],
+	})
+}
+
+// removeSilenceCommand deletes a silence by ID.
+func (rc *RestController) removeSilenceCommand(ctx context.Context, w http.ResponseWriter, hexID, username string) {
+	id, err := primitive.ObjectIDFromHex(strings.TrimSpace(hexID))
+	if err != nil {
+		respondDiscordEphemeral(w, fmt.Sprintf("ID **%s** không hợp lệ.", hexID))
+		return
+	}
+
+	suppression, err := rc.getSuppression(ctx, bson.M{"_id": id})
+	if err == nil && suppression != nil {
+		_, err = rc.deleteSuppression(ctx, id)
+	}
+	if err != nil {
+		log.Printf("Error deleting suppression: %v", err)
+		respondDiscordEphemeral(w, "Không thể bật lại thông báo, vui lòng thử lại.")
+		return
+	}
+	if suppression == nil {
+		respondDiscordEphemeral(w, fmt.Sprintf("Không tìm thấy tắt thông báo **%s**.", hexID))
+		return
+	}
+
+	log.Printf("Removed suppression %s for %s via Discord command by %s", hexID, suppression.LabelMatchers(), username)
+	respondDiscordEphemeralEmbed(w, &discordgo.MessageEmbed{
+		Title:       "Đã bật lại thông báo",
+		Description: suppression.LabelMatchers().String(),
+		Color:       discordResolvedColor,
+	})
+}
+
+// discordOptionValues collects the string options of a command by name.
+func discordOptionValues(options []*discordgo.ApplicationCommandInteractionDataOption) map[string]string {
+	values := make(map[string]string)
+	for _, option := range options {
+		if option.Type == discordgo.ApplicationCommandOptionString {
+			values[option.Name] = option.StringValue()
+		}
+	}
+	return values
+}
+
+func respondDiscordEphemeralEmbed(w http.ResponseWriter, embed *discordgo.MessageEmbed) {
+	writeDiscordResponse(w, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
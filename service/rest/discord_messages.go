@@ -0,0 +1,124 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"webhook-server/service/config"
+)
+
+const discordMessagesCollection = "discord_messages"
+
+// DiscordAlertMessage remembers the Discord message posted for a firing alert
+// so the resolve notification can edit it instead of posting a new message.
+// An alert routed to several Discord receivers has one message per receiver.
+// ThreadID is the incident thread on that message, when threads are enabled.
+type DiscordAlertMessage struct {
+	Fingerprint string    `bson:"fingerprint"`
+	Receiver    string    `bson:"receiver"`
+	ChannelID   string    `bson:"channel_id"`
+	MessageID   string    `bson:"message_id"`
+	ThreadID    string    `bson:"thread_id,omitempty"`
+	StartsAt    time.Time `bson:"starts_at"`
+	Status      string    `bson:"status"`
+	UpdatedAt   time.Time `bson:"updated_at"`
+}
+
+func (rc *RestController) discordMessages() (*mongo.Collection, error) {
+	config, err := config.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+	return rc.MongoClient.Database(config.MongoDBDatabase).Collection(discordMessagesCollection), nil
+}
+
+// saveDiscordMessage records the latest firing message for the fingerprint and
+// receiver. Repeat notifications replace the previous entry.
+func (rc *RestController) saveDiscordMessage(ctx context.Context, message DiscordAlertMessage) error {
+	collection, err := rc.discordMessages()
+	if err != nil {
+		return err
+	}
+
+	message.UpdatedAt = time.Now()
+	_, err = collection.ReplaceOne(
+		ctx,
+		bson.M{"fingerprint": message.Fingerprint, "receiver": message.Receiver},
+		message,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save Discord message: %w", err)
+	}
+	return nil
+}
+
+// findDiscordMessage returns the firing message sent to the receiver for the
+// fingerprint, or nil if none was recorded.
+func (rc *RestController) findDiscordMessage(ctx context.Context, fingerprint, receiver string) (*DiscordAlertMessage, error) {
+	collection, err := rc.discordMessages()
+	if err != nil {
+		return nil, err
+	}
+
+	var result DiscordAlertMessage
+	err = collection.FindOne(ctx, bson.M{
+		"fingerprint": fingerprint,
+		"receiver":    receiver,
+		"status":      "firing",
+	}).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find Discord message: %w", err)
+	}
+	return &result, nil
+}
+
+// findDiscordMessageFor returns the firing alert whose message or incident
+// thread is the given one, or nil if there is none.
+func (rc *RestController) findDiscordMessageFor(ctx context.Context, channelID, messageID string) (*DiscordAlertMessage, error) {
+	collection, err := rc.discordMessages()
+	if err != nil {
+		return nil, err
+	}
+
+	var result DiscordAlertMessage
+	err = collection.FindOne(ctx, bson.M{
+		"$or": bson.A{
+			bson.M{"message_id": messageID},
+			bson.M{"thread_id": channelID},
+		},
+		"status": "firing",
+	}).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find Discord message: %w", err)
+	}
+	return &result, nil
+}
+
+func (rc *RestController) markDiscordMessageResolved(ctx context.Context, fingerprint, receiver string) error {
+	collection, err := rc.discordMessages()
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.UpdateOne(
+		ctx,
+		bson.M{"fingerprint": fingerprint, "receiver": receiver},
+		bson.M{"$set": bson.M{"status": "resolved", "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update Discord message: %w", err)
+	}
+	return nil
+}
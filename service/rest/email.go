@@ -0,0 +1,32 @@
+package rest
+
+import (
+	"log"
+	"time"
+
+	"webhook-server/service/contact"
+	"webhook-server/service/model"
+	"webhook-server/service/route"
+	"webhook-server/service/templates"
+)
+
+func (rc *RestController) deliverEmail(sender contact.IEmailSender, receiver string, alert model.Alert) error {
+	message, err := rc.render(receiver, route.ReceiverEmail, alert, firingDuration(alert, time.Time{}))
+	if err != nil {
+		return err
+	}
+
+	if err := sender.SendEmail(emailSubject(alert, message), message.Body, message.HTML); err != nil {
+		return err
+	}
+	log.Printf("Sent %s alert by email for %s %s", alert.Status, alert.Labels["instance"], alert.Labels["device"])
+	return nil
+}
+
+// emailSubject is the template's title, or the alert summary without one.
+func emailSubject(alert model.Alert, message *templates.Message) string {
+	if message.Title != "" {
+		return message.Title
+	}
+	return alert.Annotations["summary"]
+}
@@ -0,0 +1,88 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"webhook-server/service/config"
+)
+
+// Mongo's error code for an index that exists with other options
+const indexOptionsConflict = 85
+
+// EnsureIndexes creates the indexes the lookups by fingerprint and the
+// delivery poller rely on. Ingestions and alert records expire after
+// retention.
+func (rc *RestController) EnsureIndexes(ctx context.Context, retention time.Duration) error {
+	config, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get config: %w", err)
+	}
+	db := rc.MongoClient.Database(config.MongoDBDatabase)
+
+	unique := options.Index().SetUnique(true)
+	expire := options.Index().SetExpireAfterSeconds(int32(retention.Seconds()))
+	indexes := map[string][]mongo.IndexModel{
+		deliveryQueueCollection: {
+			{Keys: bson.D{{Key: "next_attempt_at", Value: 1}}},
+			{Keys: bson.D{{Key: "receiver", Value: 1}, {Key: "channel", Value: 1}, {Key: "alert.fingerprint", Value: 1}}},
+		},
+		alertsCollection: {
+			{Keys: bson.D{{Key: "fingerprint", Value: 1}}, Options: unique},
+			{Keys: bson.D{{Key: "updated_at", Value: 1}}, Options: expire},
+		},
+		acknowledgedAlertsCollection: {
+			{Keys: bson.D{{Key: "fingerprint", Value: 1}}, Options: unique},
+		},
+		discordMessagesCollection: {
+			{Keys: bson.D{{Key: "fingerprint", Value: 1}, {Key: "receiver", Value: 1}}, Options: unique},
+			{Keys: bson.D{{Key: "message_id", Value: 1}}},
+			{Keys: bson.D{{Key: "thread_id", Value: 1}}},
+		},
+		suppressedAlertsCollection: {
+			{Keys: bson.D{{Key: "suppressed_until", Value: 1}}},
+		},
+		ingestionsCollection: {
+			{Keys: bson.D{{Key: "received_at", Value: 1}}, Options: expire},
+		},
+	}
+
+	for collection, models := range indexes {
+		for _, model := range models {
+			if err := ensureIndex(ctx, db, collection, model); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ensureIndex creates the index, updating the expiry of an existing TTL index
+// when the retention changed.
+func ensureIndex(ctx context.Context, db *mongo.Database, collection string, model mongo.IndexModel) error {
+	_, err := db.Collection(collection).Indexes().CreateOne(ctx, model)
+	if err == nil {
+		return nil
+	}
+
+	var cmdErr mongo.CommandError
+	if model.Options != nil && model.Options.ExpireAfterSeconds != nil && errors.As(err, &cmdErr) && cmdErr.Code == indexOptionsConflict {
+		err = db.RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: collection},
+			{Key: "index", Value: bson.D{
+				{Key: "keyPattern", Value: model.Keys},
+				{Key: "expireAfterSeconds", Value: *model.Options.ExpireAfterSeconds},
+			}},
+		}).Err()
+		if err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to create index on %s: %w", collection, err)
+}
@@ -0,0 +1,276 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"webhook-server/service/config"
+	"webhook-server/service/model"
+)
+
+const ingestionsCollection = "ingestions"
+
+// Delivery outcomes reported per alert and receiver
+const (
+	outcomeQueued     = "queued"
+	outcomeRetrying   = "retrying"
+	outcomeSent       = "sent"
+	outcomeSuppressed = "suppressed"
+	outcomeFailed     = "failed"
+	outcomeSuperseded = "superseded"
+
+	// outcomeAcknowledged is a repeat of an alert someone took ownership of
+	outcomeAcknowledged = "acknowledged"
+)
+
+// Ingestion is a webhook payload accepted for asynchronous delivery. The
+// delivery workers keep Results up to date as they send the alerts.
+type Ingestion struct {
+	ID         primitive.ObjectID `bson:"_id" json:"id"`
+	Endpoint   string             `bson:"endpoint" json:"endpoint"`
+	Source     string             `bson:"source" json:"source"`
+	GroupKey   string             `bson:"group_key,omitempty" json:"group_key,omitempty"`
+	ReceivedAt time.Time          `bson:"received_at" json:"received_at"`
+	Results    []AlertResult      `bson:"results" json:"alerts"`
+	// Report is where the final outcomes can be read once the deliveries
+	// have been sent; the webhook response only has the initial ones.
+	Report string `bson:"-" json:"report,omitempty"`
+}
+
+// AlertResult is what happened to one alert of an ingestion.
+type AlertResult struct {
+	Fingerprint string           `bson:"fingerprint,omitempty" json:"fingerprint,omitempty"`
+	Alertname   string           `bson:"alertname,omitempty" json:"alertname,omitempty"`
+	Status      string           `bson:"status" json:"status"`
+	Receivers   []ReceiverResult `bson:"receivers" json:"receivers"`
+}
+
+// ReceiverResult is the delivery outcome of an alert for one receiver.
+type ReceiverResult struct {
+	Receiver string `bson:"receiver" json:"receiver"`
+	Outcome  string `bson:"outcome" json:"outcome"`
+	Error    string `bson:"error,omitempty" json:"error,omitempty"`
+	Attempts int    `bson:"attempts,omitempty" json:"attempts,omitempty"`
+}
+
+func (rc *RestController) ingestions() (*mongo.Collection, error) {
+	config, err := config.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+	return rc.MongoClient.Database(config.MongoDBDatabase).Collection(ingestionsCollection), nil
+}
+
+func (rc *RestController) saveIngestion(ctx context.Context, ingestion *Ingestion) error {
+	collection, err := rc.ingestions()
+	if err != nil {
+		return err
+	}
+
+	if _, err := collection.InsertOne(ctx, ingestion); err != nil {
+		return fmt.Errorf("failed to save ingestion: %w", err)
+	}
+	return nil
+}
+
+// getIngestion returns the ingestion with the given ID, or nil if there is
+// none.
+func (rc *RestController) getIngestion(ctx context.Context, id primitive.ObjectID) (*Ingestion, error) {
+	collection, err := rc.ingestions()
+	if err != nil {
+		return nil, err
+	}
+
+	var result Ingestion
+	err = collection.FindOne(ctx, bson.M{"_id": id}).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ingestion: %w", err)
+	}
+	return &result, nil
+}
+
+// recordOutcome updates the result of a delivery in its ingestion. Failures
+// are only logged since they don't affect the delivery itself.
+func (rc *RestController) recordOutcome(ctx context.Context, job *DeliveryJob, outcome, reason string) {
+	id, err := primitive.ObjectIDFromHex(job.IngestionID)
+	if err != nil {
+		return
+	}
+
+	collection, err := rc.ingestions()
+	if err != nil {
+		log.Printf("Error recording delivery outcome: %v", err)
+		return
+	}
+
+	prefix := fmt.Sprintf("results.%d.receivers.$[r].", job.AlertIndex)
+	_, err = collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{
+			prefix + "outcome":  outcome,
+			prefix + "error":    reason,
+			prefix + "attempts": job.Attempts,
+		}},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"r.receiver": job.Receiver}},
+		}),
+	)
+	if err != nil {
+		log.Printf("Error recording delivery outcome for ingestion %s: %v", job.IngestionID, err)
+	}
+}
+
+// ingestWebhook decodes a webhook and queues a delivery to every receiver
+// picked by targets for each unsuppressed alert. Alerts are handled
+// independently: the 202 response reports, per alert and receiver, whether
+// the delivery was queued or suppressed, or why it failed. Sending happens in
+// the delivery workers and its outcome is kept in the ingestion.
+func (rc *RestController) ingestWebhook(w http.ResponseWriter, r *http.Request, channel bool, targets func(model.Alert) []*Receiver) {
+	alertData, err := decodeWebhookMessage(r)
+	if err != nil {
+		log.Printf("Invalid JSON body: %v", err)
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	if len(alertData.Alerts) == 0 {
+		http.Error(w, "No alerts found in request", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rc.recordAlerts(ctx, alertData.Alerts)
+
+	ingestion := &Ingestion{
+		ID:         primitive.NewObjectID(),
+		Endpoint:   r.URL.Path,
+		Source:     alertData.Source(),
+		GroupKey:   alertData.GroupKey,
+		ReceivedAt: time.Now(),
+		Results:    make([]AlertResult, len(alertData.Alerts)),
+	}
+	ingestion.Report = "/api/ingestions/" + ingestion.ID.Hex()
+
+	// Deliver rather than drop the alerts when suppressions can't be read
+	suppressions, err := rc.activeSuppressions(ctx)
+	if err != nil {
+		log.Printf("Error checking suppressions: %v", err)
+	}
+
+	receivers := make([][]*Receiver, len(alertData.Alerts))
+	for i, alert := range alertData.Alerts {
+		receivers[i] = targets(alert)
+
+		outcome := outcomeQueued
+		if isSuppressed(alert, suppressions) {
+			outcome = outcomeSuppressed
+		} else if acknowledged, err := rc.isAcknowledged(ctx, alert); err != nil {
+			log.Printf("Error checking acknowledgement for alert %s: %v", alert.Fingerprint, err)
+		} else if acknowledged {
+			outcome = outcomeAcknowledged
+		}
+		result := AlertResult{
+			Fingerprint: alert.Fingerprint,
+			Alertname:   alert.Labels["alertname"],
+			Status:      alert.Status,
+			Receivers:   []ReceiverResult{},
+		}
+		for _, receiver := range receivers[i] {
+			result.Receivers = append(result.Receivers, ReceiverResult{Receiver: receiver.Name, Outcome: outcome})
+		}
+		ingestion.Results[i] = result
+	}
+
+	// Refuse the whole batch while a receiver is backed up, so the sender
+	// retries later instead of the queue growing without bound
+	for i, result := range ingestion.Results {
+		for j, receiver := range receivers[i] {
+			if result.Receivers[j].Outcome == outcomeQueued && rc.laneFull(channel, receiver.Name) {
+				log.Printf("Delivery queue for %s is full, refusing webhook", receiver.Name)
+				w.Header().Set("Retry-After", "30")
+				http.Error(w, "Delivery queue full", http.StatusServiceUnavailable)
+				return
+			}
+		}
+	}
+
+	if err := rc.saveIngestion(ctx, ingestion); err != nil {
+		log.Printf("Error saving ingestion: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	queued, failed := 0, 0
+	for i, alert := range alertData.Alerts {
+		alertQueued := false
+		for j, receiver := range receivers[i] {
+			result := &ingestion.Results[i].Receivers[j]
+			if result.Outcome != outcomeQueued {
+				continue
+			}
+
+			job := &DeliveryJob{
+				Receiver:    receiver.Name,
+				Channel:     channel,
+				IngestionID: ingestion.ID.Hex(),
+				AlertIndex:  i,
+				Alert:       alert,
+			}
+			if err := rc.dispatch(ctx, job); err != nil {
+				log.Printf("Error queueing alert %s for %s: %v", alert.Fingerprint, receiver.Name, err)
+				result.Outcome = outcomeFailed
+				result.Error = err.Error()
+				rc.recordOutcome(ctx, job, result.Outcome, result.Error)
+				failed++
+				continue
+			}
+			queued++
+			alertQueued = true
+		}
+		// A queued resolution clears them once it has been sent
+		if !alertQueued {
+			rc.clearSuppression(ctx, alert)
+			rc.clearAcknowledgement(ctx, alert)
+		}
+	}
+
+	// Ask the sender to retry only when nothing could be queued
+	status := http.StatusAccepted
+	if failed > 0 && queued == 0 {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, ingestion)
+}
+
+// IngestionHandler returns an ingestion with the current delivery outcome of
+// each of its alerts.
+func (rc *RestController) IngestionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid ingestion ID", http.StatusBadRequest)
+		return
+	}
+
+	ingestion, err := rc.getIngestion(r.Context(), id)
+	if err != nil {
+		log.Printf("Error getting ingestion: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if ingestion == nil {
+		http.Error(w, "Ingestion not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, ingestion)
+}
@@ -0,0 +1,105 @@
+package rest
+
+import (
+	"context"
+
+	"webhook-server/service/contact"
+	"webhook-server/service/model"
+)
+
+// Notification is a single alert to send to a receiver.
+type Notification struct {
+	Alert model.Alert
+}
+
+// INotifier sends notifications to one receiver. Adding a channel only takes
+// an INotifier and a case in the receiver factory; webhooks reach it through
+// /webhook/{receiver} or the routing tree.
+type INotifier interface {
+	Name() string
+	Send(ctx context.Context, notification Notification) error
+}
+
+type telegramNotifier struct {
+	rc     *RestController
+	name   string
+	sender contact.ITelegramSender
+}
+
+func (rc *RestController) NewTelegramNotifier(name string, sender contact.ITelegramSender) INotifier {
+	return &telegramNotifier{rc: rc, name: name, sender: sender}
+}
+
+func (n *telegramNotifier) Name() string { return n.name }
+
+func (n *telegramNotifier) Send(ctx context.Context, notification Notification) error {
+	_, err := n.rc.deliverTelegram(n.sender, n.name, notification.Alert)
+	return err
+}
+
+type discordNotifier struct {
+	rc        *RestController
+	name      string
+	sender    contact.IDiscordSender
+	channelID string
+}
+
+// NewDiscordNotifier creates a Discord notifier that remembers its firing
+// messages in the controller's database so resolutions can edit them.
+func (rc *RestController) NewDiscordNotifier(name string, sender contact.IDiscordSender, channelID string) INotifier {
+	return &discordNotifier{rc: rc, name: name, sender: sender, channelID: channelID}
+}
+
+func (n *discordNotifier) Name() string { return n.name }
+
+func (n *discordNotifier) Send(ctx context.Context, notification Notification) error {
+	return n.rc.deliverDiscord(ctx, n.sender, n.channelID, n.name, notification.Alert)
+}
+
+type slackNotifier struct {
+	rc     *RestController
+	name   string
+	sender contact.ISlackSender
+}
+
+func (rc *RestController) NewSlackNotifier(name string, sender contact.ISlackSender) INotifier {
+	return &slackNotifier{rc: rc, name: name, sender: sender}
+}
+
+func (n *slackNotifier) Name() string { return n.name }
+
+func (n *slackNotifier) Send(ctx context.Context, notification Notification) error {
+	return n.rc.deliverSlack(n.sender, n.name, notification.Alert)
+}
+
+type teamsNotifier struct {
+	rc     *RestController
+	name   string
+	sender contact.ITeamsSender
+}
+
+func (rc *RestController) NewTeamsNotifier(name string, sender contact.ITeamsSender) INotifier {
+	return &teamsNotifier{rc: rc, name: name, sender: sender}
+}
+
+func (n *teamsNotifier) Name() string { return n.name }
+
+func (n *teamsNotifier) Send(ctx context.Context, notification Notification) error {
+	return n.rc.deliverTeams(n.sender, n.name, notification.Alert)
+}
+
+type emailNotifier struct {
+	rc     *RestController
+	name   string
+	sender contact.IEmailSender
+}
+
+func (rc *RestController) NewEmailNotifier(name string, sender contact.IEmailSender) INotifier {
+	return &emailNotifier{rc: rc, name: name, sender: sender}
+}
+
+func (n *emailNotifier) Name() string { return n.name }
+
+func (n *emailNotifier) Send(ctx context.Context, notification Notification) error {
+	return n.rc.deliverEmail(n.sender, n.name, notification.Alert)
+}
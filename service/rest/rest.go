@@ -9,42 +9,116 @@ import (
 	"io"
 	"log"
 	"net/http"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"webhook-server/service/config"
 	"webhook-server/service/contact"
 	"webhook-server/service/helper"
 	"webhook-server/service/model"
+	"webhook-server/service/route"
+	"webhook-server/service/templates"
 )
 
+// RestController serves the webhooks and the management API. Telegram,
+// Discord and Slack are the clients used to answer button clicks; alerts are
+// sent through the notifiers in Receivers and Channels.
 type RestController struct {
-	Telegram    contact.ITelegramSender
-	Discord     contact.IDiscordSender
-	MongoClient *mongo.Client
-}
-
-type SuppressedAlert struct {
-	NodeInstance    string    `bson:"node_instance"`
-	Device          string    `bson:"device"`
-	SuppressedUntil time.Time `bson:"suppressed_until"`
-	AlertSummary    string    `bson:"alert_summary"`
+	Telegram       contact.ITelegramSender
+	Discord        contact.IDiscordSender
+	Slack          contact.ISlackSender
+	Router         *route.Route
+	Templates      *templates.Store
+	Receivers      map[string]*Receiver
+	Channels       map[string]*Receiver
+	MongoClient    *mongo.Client
+	DiscordSession *discordgo.Session
+
+	stopWorker  context.CancelFunc
+	workers     sync.WaitGroup
+	workerSlots chan struct{}
+	lanes       map[string][]chan *DeliveryJob
+	queueSize   int
 }
 
 func (rc *RestController) SetUpRoutes() *http.ServeMux {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", rc.HealthHandler)
-	mux.HandleFunc("/telegram", rc.TelegramWebhookHandler)
-	mux.HandleFunc("/discord", rc.DiscordWebhookHandler)
+	mux.HandleFunc("/alerts", rc.AlertsWebhookHandler)
+	mux.HandleFunc("POST /webhook/{receiver}", rc.ReceiverWebhookHandler)
+	for _, channel := range []string{route.ReceiverTelegram, route.ReceiverDiscord, route.ReceiverSlack, route.ReceiverTeams, route.ReceiverEmail} {
+		mux.HandleFunc("/"+channel, rc.ChannelWebhookHandler(channel))
+	}
+	mux.HandleFunc("/telegram/updates", rc.TelegramUpdatesHandler)
 	mux.HandleFunc("/discord/interactions", rc.DiscordInteractionHandler)
+	mux.HandleFunc("/slack/interactions", rc.SlackInteractionHandler)
+
+	// The delivery report of a webhook is read-only, so senders can follow
+	// the final outcomes even when the management API is disabled
+	config, err := config.GetConfig()
+	if err != nil || config.APIToken == "" {
+		log.Printf("API_TOKEN is not set, the management API under /api is disabled")
+		mux.HandleFunc("GET /api/ingestions/{id}", rc.IngestionHandler)
+		return mux
+	}
+
+	mux.HandleFunc("GET /api/suppressions", requireAPIToken(rc.ListSuppressionsHandler))
+	mux.HandleFunc("POST /api/suppressions", requireAPIToken(rc.CreateSuppressionHandler))
+	mux.HandleFunc("GET /api/suppressions/expired", requireAPIToken(rc.ExpiredSuppressionsHandler))
+	mux.HandleFunc("GET /api/suppressions/{id}", requireAPIToken(rc.GetSuppressionHandler))
+	mux.HandleFunc("POST /api/suppressions/{id}/extend", requireAPIToken(rc.ExtendSuppressionHandler))
+	mux.HandleFunc("DELETE /api/suppressions/{id}", requireAPIToken(rc.DeleteSuppressionHandler))
+
+	mux.HandleFunc("GET /api/v2/status", requireAPIToken(rc.StatusHandler))
+	mux.HandleFunc("GET /api/v2/alerts", requireAPIToken(rc.ListAlertsHandler))
+	mux.HandleFunc("GET /api/v2/alerts/groups", requireAPIToken(rc.ListAlertGroupsHandler))
+	mux.HandleFunc("GET /api/v2/silences", requireAPIToken(rc.ListSilencesHandler))
+	mux.HandleFunc("POST /api/v2/silences", requireAPIToken(rc.PostSilencesHandler))
+	mux.HandleFunc("GET /api/v2/silence/{id}", requireAPIToken(rc.GetSilenceHandler))
+	mux.HandleFunc("DELETE /api/v2/silence/{id}", requireAPIToken(rc.DeleteSilenceHandler))
+
+	mux.HandleFunc("GET /api/receivers", requireAPIToken(rc.ListReceiversHandler))
+	mux.HandleFunc("POST /api/templates/render", requireAPIToken(rc.RenderTemplatesHandler))
+	mux.HandleFunc("GET /api/ingestions/{id}", requireAPIToken(rc.IngestionHandler))
+	mux.HandleFunc("GET /api/deliveries", requireAPIToken(rc.ListDeliveriesHandler))
+	mux.HandleFunc("GET /api/dead-letters", requireAPIToken(rc.ListDeadLettersHandler))
+	mux.HandleFunc("POST /api/dead-letters/{id}/replay", requireAPIToken(rc.ReplayDeadLetterHandler))
+	mux.HandleFunc("DELETE /api/dead-letters/{id}", requireAPIToken(rc.DeleteDeadLetterHandler))
 	return mux
 }
 
+// Close stops the delivery workers, waiting for deliveries in flight, and
+// closes the Discord and MongoDB connections.
+func (rc *RestController) Close(ctx context.Context) error {
+	if rc.stopWorker != nil {
+		rc.stopWorker()
+		done := make(chan struct{})
+		go func() {
+			rc.workers.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			log.Printf("Delivery workers did not stop in time")
+		}
+	}
+
+	if rc.DiscordSession != nil {
+		if err := rc.DiscordSession.Close(); err != nil {
+			log.Printf("Error closing Discord connection: %v", err)
+		}
+	}
+
+	if err := rc.MongoClient.Disconnect(ctx); err != nil {
+		return fmt.Errorf("failed to disconnect from MongoDB: %w", err)
+	}
+	return nil
+}
+
 func (rc *RestController) HealthHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -55,138 +129,102 @@ func (rc *RestController) HealthHandler(w http.ResponseWriter, r *http.Request)
 	w.Write([]byte("UP"))
 }
 
-func (rc *RestController) TelegramWebhookHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	var alertData model.GrafanaAlert
-	if err := json.NewDecoder(r.Body).Decode(&alertData); err != nil {
-		log.Printf("Invalid JSON body: %v", err)
-		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
-		return
+// render renders the receiver's template for the alert. Duration is how long a
+// resolved alert fired, zero when unknown.
+func (rc *RestController) render(receiver, channel string, alert model.Alert, duration time.Duration) (*templates.Message, error) {
+	message, err := rc.Templates.Render(receiver, channel, templates.Data{
+		Alert:    alert,
+		Receiver: receiver,
+		Duration: duration,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s message: %w", channel, err)
 	}
+	return message, nil
+}
 
-	if len(alertData.Alerts) == 0 {
-		http.Error(w, "No alerts found in request", http.StatusBadRequest)
-		return
+// deliverTelegram renders the alert and sends it with the suppress button when
+// firing.
+func (rc *RestController) deliverTelegram(sender contact.ITelegramSender, receiver string, alert model.Alert) ([]byte, error) {
+	message, err := rc.render(receiver, route.ReceiverTelegram, alert, firingDuration(alert, time.Time{}))
+	if err != nil {
+		return nil, err
 	}
 
-	for _, alert := range alertData.Alerts {
-		singleAlerts := []model.Alert{alert}
-		message, err := contact.RenderTelegramMessage(singleAlerts)
-		if err != nil {
-			log.Printf("Error rendering Telegram message: %v", err)
-			http.Error(w, "Error rendering message", http.StatusInternalServerError)
-			return
-		}
-
-		resp, err := rc.Telegram.SendTelegramMessage(message)
-
-		if err != nil {
-			log.Printf("Telegram message delivery failed: %v", err)
-			http.Error(w, "Message delivery failed", http.StatusInternalServerError)
-			return
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		w.Write(resp)
-	}
+	send := buildTelegramMessage(alert, message)
+	return sender.SendTelegramMessageWithKeyboard(send.Text, send.ReplyMarkup)
 }
 
-func (rc *RestController) DiscordWebhookHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	var alertData model.GrafanaAlert
-	if err := json.NewDecoder(r.Body).Decode(&alertData); err != nil {
-		log.Printf("Invalid JSON body: %v", err)
-		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
-		return
+// deliverDiscord posts a firing alert with the suppress button and remembers
+// the message, or edits that message when the alert resolves. With
+// DISCORD_THREADS, the first firing message opens a thread for the incident
+// and repeats are posted there.
+func (rc *RestController) deliverDiscord(ctx context.Context, sender contact.IDiscordSender, channelID, receiver string, alert model.Alert) error {
+	if alert.Status == "resolved" {
+		return rc.sendDiscordResolved(ctx, sender, receiver, alert)
 	}
-
-	if len(alertData.Alerts) == 0 {
-		http.Error(w, "No alerts found in request", http.StatusBadRequest)
-		return
+	if alert.Status != "firing" {
+		return nil
 	}
 
 	config, err := config.GetConfig()
 	if err != nil {
-		log.Printf("Error loading config: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+		return fmt.Errorf("failed to get config: %w", err)
 	}
+	threads := config.DiscordThreads == "true" && alert.Fingerprint != ""
 
-	collection := rc.MongoClient.Database(config.MongoDBDatabase).Collection("suppressed_alerts")
-
-	for _, alert := range alertData.Alerts {
-		nodeInstance := alert.Labels["instance"]
-		device := alert.Labels["device"]
-
-		if alert.Status == "firing" {
-			// Check if alert is suppressed
-			var result SuppressedAlert
-			err := collection.FindOne(context.TODO(), bson.M{
-				"node_instance":    nodeInstance,
-				"device":           device,
-				"suppressed_until": bson.M{"$gt": time.Now()},
-			}).Decode(&result)
-			if err == nil {
-				log.Printf("Alert suppressed for %s %s until %v", nodeInstance, device, result.SuppressedUntil)
-				continue
-			} else if err != mongo.ErrNoDocuments {
-				log.Printf("Error checking suppression: %v", err)
-				http.Error(w, "Internal server error", http.StatusInternalServerError)
-				return
-			}
+	// Build the firing embed with the suppress menu and links
+	message, err := rc.render(receiver, route.ReceiverDiscord, alert, 0)
+	if err != nil {
+		return err
+	}
+	send := buildDiscordMessage(alert, message)
+	embed, components := send.Embeds[0], send.Components
 
-			// Build and send firing message with button
-			message := buildFiringMessage(alert)
-			components := []discordgo.MessageComponent{
-				discordgo.ActionsRow{
-					Components: []discordgo.MessageComponent{
-						discordgo.Button{
-							Label:    "Tắt thông báo trong 72h",
-							Style:    discordgo.PrimaryButton,
-							CustomID: fmt.Sprintf("resolve:%s:%s", nodeInstance, device),
-						},
-					},
-				},
-			}
-			resp, err := rc.Discord.SendDiscordMessageWithComponents(message, components)
-			if err != nil {
-				log.Printf("Error sending Discord message: %v", err)
-				http.Error(w, "Error sending message", http.StatusInternalServerError)
-				return
-			}
-			log.Printf("Sent firing alert to Discord: %s", resp)
-		} else if alert.Status == "resolved" {
-			// Build and send resolved message
-			message := buildResolvedMessage(alert)
-			resp, err := rc.Discord.SendDiscordMessage(message)
+	if threads {
+		original, err := rc.findDiscordMessage(ctx, alert.Fingerprint, receiver)
+		if err != nil {
+			log.Printf("Error finding Discord message: %v", err)
+		}
+		if original != nil && original.ThreadID != "" {
+			resp, err := sender.SendThreadEmbed(original.ThreadID, embed, components)
 			if err != nil {
-				log.Printf("Error sending Discord message: %v", err)
-				http.Error(w, "Error sending message", http.StatusInternalServerError)
-				return
+				return err
 			}
-			log.Printf("Sent resolved alert to Discord: %s", resp)
+			log.Printf("Sent repeated alert to Discord thread %s: %s", original.ThreadID, resp)
+			return nil
+		}
+	}
 
-			// Remove suppression entry if it exists
-			_, err = collection.DeleteOne(context.TODO(), bson.M{
-				"node_instance": nodeInstance,
-				"device":        device,
-			})
-			if err != nil {
-				log.Printf("Error removing suppression: %v", err)
-			}
+	resp, err := sender.SendDiscordEmbed(embed, components)
+	if err != nil {
+		return err
+	}
+	log.Printf("Sent firing alert to Discord: %s", resp)
+
+	record := DiscordAlertMessage{
+		Fingerprint: alert.Fingerprint,
+		Receiver:    receiver,
+		ChannelID:   channelID,
+		MessageID:   string(resp),
+		StartsAt:    alert.StartsAt,
+		Status:      "firing",
+	}
+	if threads {
+		// Without a thread, updates go to the channel as before
+		record.ThreadID, err = sender.StartThread(channelID, string(resp), discordThreadName(alert))
+		if err != nil {
+			log.Printf("Error starting Discord thread: %v", err)
 		}
 	}
 
-	w.WriteHeader(http.StatusOK)
+	// Remember the message so the resolve notification can edit it
+	if alert.Fingerprint != "" {
+		if err := rc.saveDiscordMessage(ctx, record); err != nil {
+			log.Printf("Error saving Discord message: %v", err)
+		}
+	}
+	return nil
 }
 
 func (rc *RestController) DiscordInteractionHandler(w http.ResponseWriter, r *http.Request) {
@@ -227,93 +265,134 @@ func (rc *RestController) DiscordInteractionHandler(w http.ResponseWriter, r *ht
 		return
 	}
 
-	if interaction.Type == discordgo.InteractionMessageComponent {
-		customID := interaction.MessageComponentData().CustomID
-		if strings.HasPrefix(customID, "resolve:") {
-			parts := strings.Split(customID, ":")
-			if len(parts) != 3 {
-				log.Printf("Invalid custom ID: %s", customID)
-				return
-			}
-			nodeInstance := parts[1]
-			device := parts[2]
-
-			// Suppress alert in MongoDB
-			collection := rc.MongoClient.Database(config.MongoDBDatabase).Collection("suppressed_alerts")
-			suppressedUntil := time.Now().Add(72 * time.Hour)
-			_, err := collection.UpdateOne(
-				context.TODO(),
-				bson.M{"node_instance": nodeInstance, "device": device},
-				bson.M{"$set": bson.M{
-					"suppressed_until": suppressedUntil,
-					"alert_summary":    "User resolved via Discord",
-				}},
-				options.Update().SetUpsert(true),
-			)
-			if err != nil {
-				log.Printf("Error suppressing alert: %v", err)
-				return
-			}
+	switch interaction.Type {
+	case discordgo.InteractionMessageComponent:
+		rc.handleDiscordComponent(w, &interaction)
+	case discordgo.InteractionModalSubmit:
+		rc.handleDiscordModal(w, &interaction)
+	case discordgo.InteractionApplicationCommand:
+		rc.handleDiscordCommand(w, &interaction)
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		rc.handleDiscordAutocomplete(w, &interaction)
+	}
+}
 
-			// Update original message
-			updatedMessage := fmt.Sprintf("Thông báo cho node **%s**, device **%s** sẽ được bỏ qua trong 72h bởi %s", nodeInstance, device, interaction.Member.User.Username)
-			components := []discordgo.MessageComponent{
-				discordgo.ActionsRow{
-					Components: []discordgo.MessageComponent{
-						discordgo.Button{
-							Label:    "Tắt trong 72h",
-							Style:    discordgo.PrimaryButton,
-							CustomID: customID,
-							Disabled: true,
-						},
-					},
-				},
-			}
-			if err := rc.Discord.UpdateMessage(interaction.ChannelID, interaction.Message.ID, updatedMessage, components); err != nil {
-				log.Printf("Error updating message: %v", err)
-			}
+// sendDiscordResolved edits the original firing message into its resolved
+// state, falling back to a new message when the firing message is unknown.
+func (rc *RestController) sendDiscordResolved(ctx context.Context, sender contact.IDiscordSender, receiver string, alert model.Alert) error {
+	config, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get config: %w", err)
+	}
 
-			// Send ephemeral response
-			response := &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Content: fmt.Sprintf("Thông báo cho node **%s**, device **%s** sẽ được bỏ qua trong 72h.", nodeInstance, device),
-					Flags:   discordgo.MessageFlagsEphemeral,
-				},
-			}
-			json.NewEncoder(w).Encode(response)
+	var original *DiscordAlertMessage
+	if alert.Fingerprint != "" {
+		original, err = rc.findDiscordMessage(ctx, alert.Fingerprint, receiver)
+		if err != nil {
+			log.Printf("Error finding Discord message: %v", err)
+		}
+	}
+
+	if original == nil {
+		message, err := rc.render(receiver, route.ReceiverDiscord, alert, firingDuration(alert, time.Time{}))
+		if err != nil {
+			return err
+		}
+		send := buildDiscordMessage(alert, message)
+		resp, err := sender.SendDiscordEmbed(send.Embeds[0], send.Components)
+		if err != nil {
+			return err
 		}
+		log.Printf("Sent resolved alert to Discord: %s", resp)
+		return nil
 	}
+
+	duration := firingDuration(alert, original.StartsAt)
+	if alert.StartsAt.IsZero() {
+		alert.StartsAt = original.StartsAt
+	}
+	message, err := rc.render(receiver, route.ReceiverDiscord, alert, duration)
+	if err != nil {
+		return err
+	}
+
+	// The resolved message drops the suppress menu but keeps the links
+	send := buildDiscordMessage(alert, message)
+	if err := sender.UpdateEmbed(original.ChannelID, original.MessageID, send.Embeds[0], send.Components); err != nil {
+		return err
+	}
+	log.Printf("Updated Discord message %s to resolved", original.MessageID)
+
+	reply := fmt.Sprintf("✅ Đã giải quyết sau %s", helper.HumanizeDuration(duration))
+	if original.ThreadID != "" {
+		if _, err := sender.SendThreadMessage(original.ThreadID, reply); err != nil {
+			log.Printf("Error posting to Discord thread: %v", err)
+		}
+		if err := sender.ArchiveThread(original.ThreadID); err != nil {
+			log.Printf("Error archiving Discord thread: %v", err)
+		}
+	} else if config.DiscordResolveReply == "true" {
+		if _, err := sender.ReplyMessage(original.ChannelID, original.MessageID, reply); err != nil {
+			log.Printf("Error replying to Discord message: %v", err)
+		}
+	}
+
+	if err := rc.markDiscordMessageResolved(ctx, alert.Fingerprint, receiver); err != nil {
+		log.Printf("Error updating Discord message record: %v", err)
+	}
+	return nil
 }
 
-func buildFiringMessage(alert model.Alert) string {
-	summary := alert.Annotations["summary"]
-	nodeInstance := alert.Labels["instance"]
-	device := alert.Labels["device"]
-	uptimeYears := fmt.Sprintf("%.2f", helper.SafeDivide(alert.Values["B"], 31536000))
-
-	return fmt.Sprintf("# ❗️❗️🚨 CẢNH BÁO ❗️❗️❗️\n\n"+
-		"> 🚨 **Vấn đề:** %s\n"+
-		"> ⏳ **Thời gian hoạt động:** %s năm\n"+
-		"### 🖥️ Thông tin node:\n"+
-		"> 🔹 **Node:** %s\n"+
-		"> 🔸 **Device:** %s\n"+
-		"━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━",
-		summary, uptimeYears, nodeInstance, device)
+// firingDuration returns how long the alert fired, preferring the alert's own
+// timestamps over the recorded start time. Zero means unknown.
+func firingDuration(alert model.Alert, recordedStart time.Time) time.Duration {
+	startsAt := alert.StartsAt
+	if startsAt.IsZero() {
+		startsAt = recordedStart
+	}
+	if startsAt.IsZero() {
+		return 0
+	}
+
+	endsAt := alert.EndsAt
+	if endsAt.IsZero() || endsAt.Before(startsAt) {
+		endsAt = time.Now()
+	}
+	return endsAt.Sub(startsAt)
 }
 
-func buildResolvedMessage(alert model.Alert) string {
-	summary := alert.Annotations["summary"]
-	nodeInstance := alert.Labels["instance"]
-	device := alert.Labels["device"]
-
-	return fmt.Sprintf("# 🤟 ĐÃ GIẢI QUYẾT 🤘\n\n"+
-		"> 🔧🛠️✨ **Vấn đề:** %s\n"+
-		"### 🖥️ Thông tin node:\n"+
-		"> 🔹 **Node:** %s\n"+
-		"> 🔸 **Device:** %s\n"+
-		"━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━",
-		summary, nodeInstance, device)
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error writing JSON response: %v", err)
+	}
+}
+
+// decodeWebhookMessage decodes a Grafana or Alertmanager webhook payload.
+func decodeWebhookMessage(r *http.Request) (*model.WebhookMessage, error) {
+	var message model.WebhookMessage
+	if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+		return nil, err
+	}
+
+	inheritGroupStatus(&message)
+
+	log.Printf("Received %s webhook with %d alerts (group %s)", message.Source(), len(message.Alerts), message.GroupKey)
+	if message.TruncatedAlerts > 0 {
+		log.Printf("Webhook for group %s truncated %d alerts", message.GroupKey, message.TruncatedAlerts)
+	}
+
+	return &message, nil
+}
+
+// inheritGroupStatus gives alerts without their own status the group status.
+func inheritGroupStatus(message *model.WebhookMessage) {
+	for i := range message.Alerts {
+		if message.Alerts[i].Status == "" {
+			message.Alerts[i].Status = message.Status
+		}
+	}
 }
 
 func verifyDiscordSignature(signature, timestamp, body, publicKey string) bool {
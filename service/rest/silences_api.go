@@ -0,0 +1,304 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"webhook-server/service/helper"
+	"webhook-server/service/matcher"
+)
+
+// Silence states reported by the Alertmanager API
+const (
+	silenceStatePending = "pending"
+	silenceStateActive  = "active"
+	silenceStateExpired = "expired"
+)
+
+// silenceMatcher is a matcher as sent by amtool. Older clients leave out
+// isEqual, which Alertmanager treats as true.
+type silenceMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual *bool  `json:"isEqual,omitempty"`
+}
+
+// postableSilence is the Alertmanager API v2 body for creating or updating a
+// silence.
+type postableSilence struct {
+	ID        string           `json:"id,omitempty"`
+	Matchers  []silenceMatcher `json:"matchers"`
+	StartsAt  time.Time        `json:"startsAt"`
+	EndsAt    time.Time        `json:"endsAt"`
+	CreatedBy string           `json:"createdBy"`
+	Comment   string           `json:"comment"`
+}
+
+type silenceStatus struct {
+	State string `json:"state"`
+}
+
+// gettableSilence is a suppression in the Alertmanager API v2 format.
+type gettableSilence struct {
+	ID        string           `json:"id"`
+	Status    silenceStatus    `json:"status"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+	Matchers  matcher.Matchers `json:"matchers"`
+	StartsAt  time.Time        `json:"startsAt"`
+	EndsAt    time.Time        `json:"endsAt"`
+	CreatedBy string           `json:"createdBy"`
+	Comment   string           `json:"comment"`
+}
+
+func toGettableSilence(s *SuppressedAlert, now time.Time) gettableSilence {
+	state := silenceStateExpired
+	if s.StartsAt.After(now) {
+		state = silenceStatePending
+	} else if s.SuppressedUntil.After(now) {
+		state = silenceStateActive
+	}
+
+	updatedAt := s.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = s.StartsAt
+	}
+
+	return gettableSilence{
+		ID:        s.ID.Hex(),
+		Status:    silenceStatus{State: state},
+		UpdatedAt: updatedAt,
+		Matchers:  s.LabelMatchers(),
+		StartsAt:  s.StartsAt,
+		EndsAt:    s.SuppressedUntil,
+		CreatedBy: s.CreatedBy,
+		Comment:   s.AlertSummary,
+	}
+}
+
+// ListSilencesHandler implements GET /api/v2/silences. Like Alertmanager, the
+// filter matchers are applied to the label set formed by each silence's
+// matchers.
+func (rc *RestController) ListSilencesHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := matcher.ParseAll(r.URL.Query()["filter"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	suppressions, err := rc.listSuppressions(r.Context(), bson.M{})
+	if err != nil {
+		log.Printf("Error listing suppressions: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	silences := []gettableSilence{}
+	for i := range suppressions {
+		labels := map[string]string{}
+		for _, m := range suppressions[i].LabelMatchers() {
+			labels[m.Name] = m.Value
+		}
+		if filter.Matches(labels) {
+			silences = append(silences, toGettableSilence(&suppressions[i], now))
+		}
+	}
+	writeJSON(w, http.StatusOK, silences)
+}
+
+// GetSilenceHandler implements GET /api/v2/silence/{id}.
+func (rc *RestController) GetSilenceHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid silence ID", http.StatusBadRequest)
+		return
+	}
+
+	suppression, err := rc.getSuppression(r.Context(), bson.M{"_id": id})
+	if err != nil {
+		log.Printf("Error getting suppression: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if suppression == nil {
+		http.Error(w, "Silence not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, toGettableSilence(suppression, time.Now()))
+}
+
+// PostSilencesHandler implements POST /api/v2/silences. A silence with an ID
+// is updated in place unless it already expired, in which case a new one is
+// created, as Alertmanager does.
+func (rc *RestController) PostSilencesHandler(w http.ResponseWriter, r *http.Request) {
+	var req postableSilence
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	matchers, err := req.matchers()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	if req.StartsAt.Before(now) {
+		req.StartsAt = now
+	}
+
+	if req.ID != "" {
+		id, err := primitive.ObjectIDFromHex(req.ID)
+		if err != nil {
+			http.Error(w, "Invalid silence ID", http.StatusBadRequest)
+			return
+		}
+
+		current, err := rc.getSuppression(r.Context(), bson.M{"_id": id})
+		if err != nil {
+			log.Printf("Error getting suppression: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if current == nil {
+			http.Error(w, "Silence not found", http.StatusNotFound)
+			return
+		}
+
+		if current.SuppressedUntil.After(now) {
+			sortMatchers(matchers)
+			updated, err := rc.updateSuppression(r.Context(), id, bson.M{
+				"$set": bson.M{
+					"matchers":         matchers,
+					"starts_at":        req.StartsAt,
+					"suppressed_until": req.EndsAt,
+					"created_by":       req.CreatedBy,
+					"alert_summary":    req.Comment,
+					"updated_at":       now,
+				},
+				"$unset": bson.M{"node_instance": "", "device": "", "fingerprint": ""},
+			})
+			if err != nil {
+				log.Printf("Error updating suppression: %v", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			if updated == nil {
+				http.Error(w, "Silence not found", http.StatusNotFound)
+				return
+			}
+
+			log.Printf("Updated silence %s via Alertmanager API", id.Hex())
+			writeJSON(w, http.StatusOK, map[string]string{"silenceID": id.Hex()})
+			return
+		}
+	}
+
+	suppression := &SuppressedAlert{
+		Matchers:        matchers,
+		StartsAt:        req.StartsAt,
+		SuppressedUntil: req.EndsAt,
+		CreatedBy:       req.CreatedBy,
+		AlertSummary:    req.Comment,
+	}
+	if err := rc.createSuppression(r.Context(), suppression); err != nil {
+		log.Printf("Error creating suppression: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Created silence %s for %s via Alertmanager API", suppression.ID.Hex(), matchers)
+	writeJSON(w, http.StatusOK, map[string]string{"silenceID": suppression.ID.Hex()})
+}
+
+// DeleteSilenceHandler implements DELETE /api/v2/silence/{id}. The silence is
+// expired rather than removed so it still shows up as expired.
+func (rc *RestController) DeleteSilenceHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid silence ID", http.StatusBadRequest)
+		return
+	}
+
+	current, err := rc.getSuppression(r.Context(), bson.M{"_id": id})
+	if err != nil {
+		log.Printf("Error getting suppression: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if current == nil {
+		http.Error(w, "Silence not found", http.StatusNotFound)
+		return
+	}
+
+	now := time.Now()
+	if !current.SuppressedUntil.After(now) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	set := bson.M{"suppressed_until": now, "updated_at": now}
+	if current.StartsAt.After(now) {
+		set["starts_at"] = now
+	}
+	if _, err := rc.updateSuppression(r.Context(), id, bson.M{"$set": set}); err != nil {
+		log.Printf("Error expiring suppression: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Expired silence %s via Alertmanager API", id.Hex())
+	w.WriteHeader(http.StatusOK)
+}
+
+func (req *postableSilence) matchers() (matcher.Matchers, error) {
+	if len(req.Matchers) == 0 {
+		return nil, fmt.Errorf("at least one matcher is required")
+	}
+
+	matchers := make(matcher.Matchers, 0, len(req.Matchers))
+	for _, m := range req.Matchers {
+		if m.Name == "" {
+			return nil, fmt.Errorf("matcher name is required")
+		}
+		isEqual := m.IsEqual == nil || *m.IsEqual
+		parsed, err := matcher.New(m.Name, m.Value, m.IsRegex, isEqual)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, parsed)
+	}
+	return matchers, nil
+}
+
+func (req *postableSilence) validate() error {
+	if req.StartsAt.IsZero() || req.EndsAt.IsZero() {
+		return fmt.Errorf("startsAt and endsAt are required")
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		return fmt.Errorf("end time must not be before start time")
+	}
+	if !req.EndsAt.After(time.Now()) {
+		return fmt.Errorf("end time can't be in the past")
+	}
+	start := req.StartsAt
+	if start.Before(time.Now()) {
+		start = time.Now()
+	}
+	if req.EndsAt.Sub(start) > maxSuppressDuration {
+		return fmt.Errorf("silence cannot last longer than %s", helper.HumanizeDuration(maxSuppressDuration))
+	}
+	return nil
+}
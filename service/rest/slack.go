@@ -0,0 +1,205 @@
+package rest
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"webhook-server/service/config"
+	"webhook-server/service/contact"
+	"webhook-server/service/model"
+	"webhook-server/service/route"
+	"webhook-server/service/templates"
+)
+
+const slackSuppressAction = "suppress"
+
+// deliverSlack posts the alert as Block Kit, with the suppress button when
+// firing.
+func (rc *RestController) deliverSlack(sender contact.ISlackSender, receiver string, alert model.Alert) error {
+	message, err := rc.render(receiver, route.ReceiverSlack, alert, firingDuration(alert, time.Time{}))
+	if err != nil {
+		return err
+	}
+
+	resp, err := sender.SendSlackMessage(alert.Annotations["summary"], buildSlackBlocks(alert, message))
+	if err != nil {
+		return err
+	}
+	log.Printf("Sent %s alert to Slack: %s", alert.Status, resp)
+	return nil
+}
+
+func (rc *RestController) SlackInteractionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if rc.Slack == nil {
+		http.Error(w, "Slack notifications disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	config, err := config.GetConfig()
+	if err != nil {
+		log.Printf("Error loading config: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// Verify Slack signature
+	signature := r.Header.Get("X-Slack-Signature")
+	timestamp := r.Header.Get("X-Slack-Request-Timestamp")
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error reading request body: %v", err)
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if !verifySlackSignature(signature, timestamp, body, config.SlackSigningSecret) {
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		log.Printf("Invalid interaction form: %v", err)
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	var interaction model.SlackInteraction
+	if err := json.Unmarshal([]byte(form.Get("payload")), &interaction); err != nil {
+		log.Printf("Invalid interaction JSON: %v", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if interaction.Type != "block_actions" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	for _, action := range interaction.Actions {
+		if action.ActionID != slackSuppressAction {
+			continue
+		}
+
+		target, err := rc.resolveAlertKey(context.TODO(), suppressKeyPrefix, action.Value)
+		if err != nil {
+			log.Printf("Error resolving alert for %s: %v", action.Value, err)
+			rc.respondSlackEphemeral(&interaction, "Không tìm thấy cảnh báo để tắt thông báo.")
+			continue
+		}
+
+		username := interaction.User.Username
+		if username == "" {
+			username = interaction.User.Name
+		}
+
+		suppressedUntil := time.Now().Add(suppressDuration)
+		if err := rc.suppressTarget(context.TODO(), target, suppressedUntil, username, "User resolved via Slack"); err != nil {
+			log.Printf("Error suppressing alert: %v", err)
+			rc.respondSlackEphemeral(&interaction, "Không thể tắt thông báo, vui lòng thử lại.")
+			continue
+		}
+
+		// Keep the alert, replacing the suppress button with the note
+		updatedMessage := fmt.Sprintf("🔕 Thông báo %s sẽ được bỏ qua trong 72h bởi %s", target.describe(slackBold), username)
+		var blocks []model.SlackBlock
+		for _, block := range interaction.Message.Blocks {
+			if block.Type != "actions" {
+				blocks = append(blocks, block)
+			}
+		}
+		blocks = append(blocks, model.SlackBlock{
+			Type:     "context",
+			Elements: []model.SlackElement{{Type: "mrkdwn", Text: updatedMessage}},
+		})
+		text := interaction.Message.Text
+		if text == "" {
+			text = updatedMessage
+		}
+		if err := rc.Slack.UpdateSlackMessage(interaction.Channel.ID, interaction.Message.TS, text, blocks); err != nil {
+			log.Printf("Error updating message: %v", err)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// respondSlackEphemeral tells the user who clicked what went wrong. Slack
+// only shows errors sent to the response URL, not the HTTP status.
+func (rc *RestController) respondSlackEphemeral(interaction *model.SlackInteraction, text string) {
+	if interaction.ResponseURL == "" {
+		return
+	}
+	if err := rc.Slack.RespondSlackEphemeral(interaction.ResponseURL, text); err != nil {
+		log.Printf("Error responding to Slack interaction: %v", err)
+	}
+}
+
+// buildSlackBlocks lays out the rendered message, adding the suppress button
+// when firing.
+func buildSlackBlocks(alert model.Alert, message *templates.Message) []model.SlackBlock {
+	var blocks []model.SlackBlock
+	if message.Title != "" {
+		blocks = append(blocks, model.SlackBlock{
+			Type: "header",
+			Text: &model.SlackText{Type: "plain_text", Text: message.Title, Emoji: true},
+		})
+	}
+	blocks = append(blocks, model.SlackBlock{
+		Type: "section",
+		Text: &model.SlackText{Type: "mrkdwn", Text: message.Body},
+	})
+
+	if alert.Status == "firing" {
+		blocks = append(blocks, model.SlackBlock{
+			Type: "actions",
+			Elements: []model.SlackElement{
+				{
+					Type:     "button",
+					Text:     model.SlackText{Type: "plain_text", Text: "Tắt thông báo trong 72h"},
+					ActionID: slackSuppressAction,
+					Value:    alertKey(suppressKeyPrefix, alert),
+					Style:    "primary",
+				},
+			},
+		})
+	}
+	return blocks
+}
+
+func slackBold(text string) string {
+	return "*" + text + "*"
+}
+
+// verifySlackSignature checks the v0 request signature and rejects requests
+// older than five minutes to prevent replays.
+func verifySlackSignature(signature, timestamp string, body []byte, secret string) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	age := time.Since(time.Unix(ts, 0))
+	if age > 5*time.Minute || age < -5*time.Minute {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -0,0 +1,419 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"webhook-server/service/config"
+	"webhook-server/service/helper"
+	"webhook-server/service/matcher"
+	"webhook-server/service/model"
+)
+
+const (
+	suppressedAlertsCollection = "suppressed_alerts"
+	suppressDuration           = 72 * time.Hour
+	suppressKeyPrefix          = "resolve:"
+	maxSuppressDuration        = 30 * 24 * time.Hour
+)
+
+// suppressChoice is a silence duration offered by the chat buttons and menus.
+type suppressChoice struct {
+	Label string
+	Value string
+}
+
+var suppressChoices = []suppressChoice{
+	{Label: "1 giờ", Value: "1h"},
+	{Label: "4 giờ", Value: "4h"},
+	{Label: "24 giờ", Value: "24h"},
+	{Label: "72 giờ", Value: "72h"},
+	{Label: "1 tuần", Value: "1w"},
+}
+
+// suppressLabel names a silence duration like the choice it was picked from.
+func suppressLabel(duration time.Duration) string {
+	for _, choice := range suppressChoices {
+		if d, err := helper.ParseDuration(choice.Value); err == nil && d == duration {
+			return choice.Label
+		}
+	}
+	return helper.HumanizeDuration(duration)
+}
+
+// SuppressedAlert is a silence: alerts whose labels match all Matchers
+// between StartsAt and SuppressedUntil are not delivered. Entries created
+// before label matchers carry only NodeInstance and Device, which are matched
+// as instance and device labels.
+type SuppressedAlert struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Matchers        matcher.Matchers   `bson:"matchers,omitempty" json:"matchers"`
+	StartsAt        time.Time          `bson:"starts_at,omitempty" json:"starts_at"`
+	SuppressedUntil time.Time          `bson:"suppressed_until" json:"suppressed_until"`
+	CreatedBy       string             `bson:"created_by,omitempty" json:"created_by"`
+	AlertSummary    string             `bson:"alert_summary" json:"alert_summary"`
+	Fingerprint     string             `bson:"fingerprint,omitempty" json:"fingerprint,omitempty"`
+	UpdatedAt       time.Time          `bson:"updated_at,omitempty" json:"updated_at"`
+
+	NodeInstance string `bson:"node_instance,omitempty" json:"node_instance,omitempty"`
+	Device       string `bson:"device,omitempty" json:"device,omitempty"`
+}
+
+// LabelMatchers returns the matchers of the silence, translating legacy
+// node and device entries.
+func (s *SuppressedAlert) LabelMatchers() matcher.Matchers {
+	if len(s.Matchers) > 0 {
+		return s.Matchers
+	}
+	return matcher.Matchers{
+		{Name: "instance", Value: s.NodeInstance, IsEqual: true},
+		{Name: "device", Value: s.Device, IsEqual: true},
+	}
+}
+
+// IsActive reports whether the silence mutes alerts at the given time.
+func (s *SuppressedAlert) IsActive(now time.Time) bool {
+	return !s.StartsAt.After(now) && s.SuppressedUntil.After(now)
+}
+
+func (rc *RestController) suppressedAlerts() (*mongo.Collection, error) {
+	config, err := config.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+	return rc.MongoClient.Database(config.MongoDBDatabase).Collection(suppressedAlertsCollection), nil
+}
+
+// activeSuppressions returns the silences that have not expired yet, with
+// their matchers compiled, so a batch of alerts is matched in memory.
+func (rc *RestController) activeSuppressions(ctx context.Context) ([]SuppressedAlert, error) {
+	suppressions, err := rc.listSuppressions(ctx, bson.M{"suppressed_until": bson.M{"$gt": time.Now()}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check suppression: %w", err)
+	}
+	return suppressions, nil
+}
+
+// matchSuppression returns the first of the silences active at now that
+// matches the labels, or nil if notifications are not suppressed.
+func matchSuppression(suppressions []SuppressedAlert, labels map[string]string, now time.Time) *SuppressedAlert {
+	for i := range suppressions {
+		if suppressions[i].IsActive(now) && suppressions[i].LabelMatchers().Matches(labels) {
+			return &suppressions[i]
+		}
+	}
+	return nil
+}
+
+// createSuppression inserts a new silence and fills in its ID.
+func (rc *RestController) createSuppression(ctx context.Context, suppression *SuppressedAlert) error {
+	if len(suppression.Matchers) == 0 {
+		return fmt.Errorf("suppression needs at least one matcher")
+	}
+
+	collection, err := rc.suppressedAlerts()
+	if err != nil {
+		return err
+	}
+
+	sortMatchers(suppression.Matchers)
+	if suppression.StartsAt.IsZero() {
+		suppression.StartsAt = time.Now()
+	}
+	suppression.UpdatedAt = time.Now()
+
+	result, err := collection.InsertOne(ctx, suppression)
+	if err != nil {
+		return fmt.Errorf("failed to create suppression: %w", err)
+	}
+	if id, ok := result.InsertedID.(primitive.ObjectID); ok {
+		suppression.ID = id
+	}
+	return nil
+}
+
+// suppressTarget silences the alert behind a chat button. Clicking again while
+// a silence for the same alert is active moves its end instead of stacking
+// another silence.
+func (rc *RestController) suppressTarget(ctx context.Context, target *suppressTarget, until time.Time, createdBy, summary string) error {
+	collection, err := rc.suppressedAlerts()
+	if err != nil {
+		return err
+	}
+
+	sortMatchers(target.Matchers)
+	filter := bson.M{"matchers": target.Matchers, "suppressed_until": bson.M{"$gt": time.Now()}}
+	if target.Fingerprint != "" {
+		filter = bson.M{"fingerprint": target.Fingerprint, "suppressed_until": bson.M{"$gt": time.Now()}}
+	}
+
+	result, err := collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"suppressed_until": until,
+		"created_by":       createdBy,
+		"alert_summary":    summary,
+		"updated_at":       time.Now(),
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to suppress alert: %w", err)
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	return rc.createSuppression(ctx, &SuppressedAlert{
+		Matchers:        target.Matchers,
+		SuppressedUntil: until,
+		CreatedBy:       createdBy,
+		AlertSummary:    summary,
+		Fingerprint:     target.Fingerprint,
+	})
+}
+
+// removeSuppression deletes the silences created from buttons for the alert,
+// along with legacy entries for its node and device.
+func (rc *RestController) removeSuppression(ctx context.Context, alert model.Alert) error {
+	collection, err := rc.suppressedAlerts()
+	if err != nil {
+		return err
+	}
+
+	conditions := bson.A{bson.M{
+		"matchers":      bson.M{"$exists": false},
+		"node_instance": alert.Labels["instance"],
+		"device":        alert.Labels["device"],
+	}}
+	if alert.Fingerprint != "" {
+		conditions = append(conditions, bson.M{"fingerprint": alert.Fingerprint})
+	}
+
+	_, err = collection.DeleteMany(ctx, bson.M{"$or": conditions})
+	if err != nil {
+		return fmt.Errorf("failed to remove suppression: %w", err)
+	}
+	return nil
+}
+
+// unsuppressTarget deletes the silences a chat button created for the alert,
+// along with legacy entries for its node and device, and returns how many
+// were deleted.
+func (rc *RestController) unsuppressTarget(ctx context.Context, target *suppressTarget) (int64, error) {
+	collection, err := rc.suppressedAlerts()
+	if err != nil {
+		return 0, err
+	}
+
+	sortMatchers(target.Matchers)
+	conditions := bson.A{bson.M{"matchers": target.Matchers}}
+	if target.Fingerprint != "" {
+		conditions = append(conditions, bson.M{"fingerprint": target.Fingerprint})
+	} else {
+		conditions = append(conditions, bson.M{
+			"matchers":      bson.M{"$exists": false},
+			"node_instance": target.Labels["instance"],
+			"device":        target.Labels["device"],
+		})
+	}
+
+	result, err := collection.DeleteMany(ctx, bson.M{"$or": conditions})
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove suppression: %w", err)
+	}
+	return result.DeletedCount, nil
+}
+
+// listSuppressions returns the suppressions matching the filter, soonest
+// expiry first.
+func (rc *RestController) listSuppressions(ctx context.Context, filter bson.M) ([]SuppressedAlert, error) {
+	collection, err := rc.suppressedAlerts()
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.M{"suppressed_until": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list suppressions: %w", err)
+	}
+
+	results := []SuppressedAlert{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode suppressions: %w", err)
+	}
+	// Decoded matchers have no compiled expression
+	for i := range results {
+		for _, m := range results[i].Matchers {
+			if err := m.Compile(); err != nil {
+				log.Printf("Suppression %s has an invalid matcher: %v", results[i].ID.Hex(), err)
+			}
+		}
+	}
+	return results, nil
+}
+
+// getSuppression returns the suppression matching the filter regardless of
+// expiry, or nil if there is none.
+func (rc *RestController) getSuppression(ctx context.Context, filter bson.M) (*SuppressedAlert, error) {
+	collection, err := rc.suppressedAlerts()
+	if err != nil {
+		return nil, err
+	}
+
+	var result SuppressedAlert
+	err = collection.FindOne(ctx, filter).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suppression: %w", err)
+	}
+	return &result, nil
+}
+
+// updateSuppression applies the update to the suppression with the given ID
+// and returns the updated document, or nil if it does not exist.
+func (rc *RestController) updateSuppression(ctx context.Context, id primitive.ObjectID, update bson.M) (*SuppressedAlert, error) {
+	collection, err := rc.suppressedAlerts()
+	if err != nil {
+		return nil, err
+	}
+
+	var result SuppressedAlert
+	err = collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update suppression: %w", err)
+	}
+	return &result, nil
+}
+
+// deleteSuppression deletes the suppression with the given ID and reports
+// whether it existed.
+func (rc *RestController) deleteSuppression(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	collection, err := rc.suppressedAlerts()
+	if err != nil {
+		return false, err
+	}
+
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete suppression: %w", err)
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// isSuppressed reports whether a firing alert is muted by one of the active
+// silences. Resolved alerts are never suppressed.
+func isSuppressed(alert model.Alert, suppressions []SuppressedAlert) bool {
+	if alert.Status != "firing" {
+		return false
+	}
+
+	if suppression := matchSuppression(suppressions, alert.Labels, time.Now()); suppression != nil {
+		log.Printf("Alert %s suppressed by %s until %v", alert.Fingerprint, suppression.LabelMatchers(), suppression.SuppressedUntil)
+		return true
+	}
+	return false
+}
+
+// clearSuppression removes the button silence of a resolved alert, so the
+// next incident notifies again. It runs once the resolution has been sent to a
+// receiver, or when the webhook is accepted if nothing was queued for it.
+func (rc *RestController) clearSuppression(ctx context.Context, alert model.Alert) {
+	if alert.Status != "resolved" {
+		return
+	}
+	if err := rc.removeSuppression(ctx, alert); err != nil {
+		log.Printf("Error removing suppression: %v", err)
+	}
+}
+
+// suppressTarget is the alert a chat button refers to.
+type suppressTarget struct {
+	Key         string
+	Fingerprint string
+	Labels      map[string]string
+	Matchers    matcher.Matchers
+}
+
+// describe names the alert for confirmation messages, using bold for the
+// platform's markup.
+func (t *suppressTarget) describe(bold func(string) string) string {
+	text := fmt.Sprintf("cho node %s, device %s", bold(t.Labels["instance"]), bold(t.Labels["device"]))
+	if name := t.Labels["alertname"]; name != "" {
+		text = bold(name) + " " + text
+	}
+	return text
+}
+
+// alertKey encodes the alert into a button or menu payload for the chat
+// integrations: its fingerprint, or node and device for alerts without one.
+func alertKey(prefix string, alert model.Alert) string {
+	if alert.Fingerprint != "" {
+		return prefix + alert.Fingerprint
+	}
+	return prefix + alert.Labels["instance"] + ":" + alert.Labels["device"]
+}
+
+// resolveAlertKey reverses alertKey. Fingerprint keys silence exactly the
+// labels of the recorded alert; node and device keys silence every alert for
+// that device. Instances usually contain a port, so the device is taken from
+// after the last colon.
+func (rc *RestController) resolveAlertKey(ctx context.Context, prefix, key string) (*suppressTarget, error) {
+	rest, found := strings.CutPrefix(key, prefix)
+	if !found {
+		return nil, fmt.Errorf("invalid key %q", key)
+	}
+
+	i := strings.LastIndex(rest, ":")
+	if i >= 0 {
+		labels := map[string]string{"instance": rest[:i], "device": rest[i+1:]}
+		return &suppressTarget{
+			Key:    key,
+			Labels: labels,
+			Matchers: matcher.Matchers{
+				{Name: "instance", Value: labels["instance"], IsEqual: true},
+				{Name: "device", Value: labels["device"], IsEqual: true},
+			},
+		}, nil
+	}
+
+	record, err := rc.findAlertRecord(ctx, rest)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, fmt.Errorf("unknown alert %s", rest)
+	}
+
+	matchers := make(matcher.Matchers, 0, len(record.Labels))
+	for name, value := range record.Labels {
+		matchers = append(matchers, &matcher.Matcher{Name: name, Value: value, IsEqual: true})
+	}
+	return &suppressTarget{
+		Key:         key,
+		Fingerprint: record.Fingerprint,
+		Labels:      record.Labels,
+		Matchers:    matchers,
+	}, nil
+}
+
+// sortMatchers orders matchers by name so equal sets compare equal in Mongo.
+func sortMatchers(matchers matcher.Matchers) {
+	sort.Slice(matchers, func(i, j int) bool {
+		if matchers[i].Name != matchers[j].Name {
+			return matchers[i].Name < matchers[j].Name
+		}
+		return matchers[i].Value < matchers[j].Value
+	})
+}
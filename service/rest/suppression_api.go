@@ -0,0 +1,293 @@
+package rest
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"webhook-server/service/config"
+	"webhook-server/service/helper"
+	"webhook-server/service/matcher"
+)
+
+// suppressionRequest is the body for creating or extending a suppression.
+// Matchers use the routing syntax, e.g. `alertname="DiskFull"` or
+// `instance=~"db-.*"`; node_instance and device are shorthand for equality
+// matchers on those labels. Either a duration such as "4h" or "2d", or an
+// absolute end time is needed.
+type suppressionRequest struct {
+	Matchers        []string   `json:"matchers"`
+	NodeInstance    string     `json:"node_instance"`
+	Device          string     `json:"device"`
+	StartsAt        *time.Time `json:"starts_at"`
+	Duration        string     `json:"duration"`
+	SuppressedUntil *time.Time `json:"suppressed_until"`
+	CreatedBy       string     `json:"created_by"`
+	AlertSummary    string     `json:"alert_summary"`
+}
+
+// requireAPIToken guards the management API with API_TOKEN. Without a token
+// the routes are not registered at all.
+func requireAPIToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		config, err := config.GetConfig()
+		if err != nil {
+			log.Printf("Error loading config: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if config.APIToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(config.APIToken)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// ListSuppressionsHandler lists suppressions, optionally filtered by
+// node_instance, device, created_by and state (active, expired or all; default
+// active). The label filters match equality matchers on instance and device.
+func (rc *RestController) ListSuppressionsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var conditions bson.A
+	for param, label := range map[string]string{"node_instance": "instance", "device": "device"} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		conditions = append(conditions, bson.M{"$or": bson.A{
+			bson.M{param: value},
+			bson.M{"matchers": bson.M{"$elemMatch": bson.M{"name": label, "value": value, "is_regex": false, "is_equal": true}}},
+		}})
+	}
+	filter := bson.M{}
+	if len(conditions) > 0 {
+		filter["$and"] = conditions
+	}
+	if createdBy := query.Get("created_by"); createdBy != "" {
+		filter["created_by"] = createdBy
+	}
+
+	switch query.Get("state") {
+	case "", "active":
+		filter["suppressed_until"] = bson.M{"$gt": time.Now()}
+	case "expired":
+		filter["suppressed_until"] = bson.M{"$lte": time.Now()}
+	case "all":
+	default:
+		http.Error(w, "Invalid state, expected active, expired or all", http.StatusBadRequest)
+		return
+	}
+
+	rc.writeSuppressions(w, r.Context(), filter)
+}
+
+func (rc *RestController) ExpiredSuppressionsHandler(w http.ResponseWriter, r *http.Request) {
+	rc.writeSuppressions(w, r.Context(), bson.M{"suppressed_until": bson.M{"$lte": time.Now()}})
+}
+
+func (rc *RestController) writeSuppressions(w http.ResponseWriter, ctx context.Context, filter bson.M) {
+	suppressions, err := rc.listSuppressions(ctx, filter)
+	if err != nil {
+		log.Printf("Error listing suppressions: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, suppressions)
+}
+
+func (rc *RestController) GetSuppressionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid suppression ID", http.StatusBadRequest)
+		return
+	}
+
+	suppression, err := rc.getSuppression(r.Context(), bson.M{"_id": id})
+	if err != nil {
+		log.Printf("Error getting suppression: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if suppression == nil {
+		http.Error(w, "Suppression not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, suppression)
+}
+
+// CreateSuppressionHandler creates a new silence.
+func (rc *RestController) CreateSuppressionHandler(w http.ResponseWriter, r *http.Request) {
+	var req suppressionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	matchers, err := req.matchers()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	startsAt := time.Now()
+	if req.StartsAt != nil && req.StartsAt.After(startsAt) {
+		startsAt = *req.StartsAt
+	}
+	until, err := req.until(startsAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	summary := req.AlertSummary
+	if summary == "" {
+		summary = "Suppressed via API"
+	}
+	suppression := &SuppressedAlert{
+		Matchers:        matchers,
+		StartsAt:        startsAt,
+		SuppressedUntil: until,
+		CreatedBy:       req.CreatedBy,
+		AlertSummary:    summary,
+	}
+	if err := rc.createSuppression(r.Context(), suppression); err != nil {
+		log.Printf("Error suppressing alert: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Suppressed %s until %v via API", matchers, until)
+	writeJSON(w, http.StatusCreated, suppression)
+}
+
+// ExtendSuppressionHandler pushes the end of a suppression back by a duration,
+// counted from its current end or from now if it already expired, or sets a
+// new absolute end time.
+func (rc *RestController) ExtendSuppressionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid suppression ID", http.StatusBadRequest)
+		return
+	}
+
+	var req suppressionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	current, err := rc.getSuppression(r.Context(), bson.M{"_id": id})
+	if err != nil {
+		log.Printf("Error getting suppression: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if current == nil {
+		http.Error(w, "Suppression not found", http.StatusNotFound)
+		return
+	}
+
+	base := current.SuppressedUntil
+	if base.Before(time.Now()) {
+		base = time.Now()
+	}
+	until, err := req.until(base)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	set := bson.M{"suppressed_until": until}
+	if req.AlertSummary != "" {
+		set["alert_summary"] = req.AlertSummary
+	}
+	suppression, err := rc.updateSuppression(r.Context(), id, bson.M{"$set": set})
+	if err != nil {
+		log.Printf("Error extending suppression: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if suppression == nil {
+		http.Error(w, "Suppression not found", http.StatusNotFound)
+		return
+	}
+	log.Printf("Extended suppression %s until %v via API", suppression.LabelMatchers(), until)
+	writeJSON(w, http.StatusOK, suppression)
+}
+
+func (rc *RestController) DeleteSuppressionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid suppression ID", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := rc.deleteSuppression(r.Context(), id)
+	if err != nil {
+		log.Printf("Error deleting suppression: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		http.Error(w, "Suppression not found", http.StatusNotFound)
+		return
+	}
+	log.Printf("Deleted suppression %s via API", id.Hex())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// matchers parses the requested matchers, falling back to node_instance and
+// device.
+func (req *suppressionRequest) matchers() (matcher.Matchers, error) {
+	matchers, err := matcher.ParseAll(req.Matchers)
+	if err != nil {
+		return nil, err
+	}
+	if req.NodeInstance != "" {
+		matchers = append(matchers, &matcher.Matcher{Name: "instance", Value: req.NodeInstance, IsEqual: true})
+	}
+	if req.Device != "" {
+		matchers = append(matchers, &matcher.Matcher{Name: "device", Value: req.Device, IsEqual: true})
+	}
+	if len(matchers) == 0 {
+		return nil, fmt.Errorf("matchers, node_instance or device is required")
+	}
+	return matchers, nil
+}
+
+// until resolves the requested end time, adding Duration to base when no
+// absolute time is given.
+func (req *suppressionRequest) until(base time.Time) (time.Time, error) {
+	var until time.Time
+	if req.SuppressedUntil != nil {
+		until = *req.SuppressedUntil
+	} else {
+		if req.Duration == "" {
+			return time.Time{}, fmt.Errorf("duration or suppressed_until is required")
+		}
+		duration, err := helper.ParseDuration(req.Duration)
+		if err != nil || duration <= 0 {
+			return time.Time{}, fmt.Errorf("invalid duration %q", req.Duration)
+		}
+		until = base.Add(duration)
+	}
+
+	if !until.After(time.Now()) || !until.After(base) {
+		return time.Time{}, fmt.Errorf("suppression must end in the future")
+	}
+	if until.After(base.Add(maxSuppressDuration)) {
+		return time.Time{}, fmt.Errorf("suppression cannot last longer than %s", helper.HumanizeDuration(maxSuppressDuration))
+	}
+	return until, nil
+}
@@ -0,0 +1,63 @@
+package rest
+
+import (
+	"log"
+	"time"
+
+	"webhook-server/service/contact"
+	"webhook-server/service/model"
+	"webhook-server/service/route"
+	"webhook-server/service/templates"
+)
+
+func (rc *RestController) deliverTeams(sender contact.ITeamsSender, receiver string, alert model.Alert) error {
+	message, err := rc.render(receiver, route.ReceiverTeams, alert, firingDuration(alert, time.Time{}))
+	if err != nil {
+		return err
+	}
+
+	if _, err := sender.SendTeamsCard(buildTeamsCard(alert, message)); err != nil {
+		return err
+	}
+	log.Printf("Sent %s alert to Teams for %s %s", alert.Status, alert.Labels["instance"], alert.Labels["device"])
+	return nil
+}
+
+func buildTeamsCard(alert model.Alert, message *templates.Message) model.AdaptiveCard {
+	color := "Attention"
+	if alert.Status == "resolved" {
+		color = "Good"
+	}
+
+	var body []model.AdaptiveElement
+	if message.Title != "" {
+		body = append(body, model.AdaptiveElement{Type: "TextBlock", Text: message.Title, Size: "Large", Weight: "Bolder", Color: color, Wrap: true})
+	}
+	body = append(body, model.AdaptiveElement{Type: "TextBlock", Text: message.Body, Wrap: true})
+
+	var actions []model.AdaptiveAction
+	links := []struct{ title, url string }{
+		{"Dashboard", alert.DashboardURL},
+		{"Panel", alert.PanelURL},
+		{"Silence", alert.SilenceURL},
+	}
+	for _, link := range links {
+		if link.url == "" {
+			continue
+		}
+		// Silencing a resolved alert makes no sense
+		if link.title == "Silence" && alert.Status == "resolved" {
+			continue
+		}
+		actions = append(actions, model.AdaptiveAction{Type: "Action.OpenUrl", Title: link.title, URL: link.url})
+	}
+
+	return model.AdaptiveCard{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+		Body:    body,
+		Actions: actions,
+		MSTeams: map[string]string{"width": "Full"},
+	}
+}
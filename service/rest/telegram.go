@@ -0,0 +1,312 @@
+package rest
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"webhook-server/service/config"
+	"webhook-server/service/helper"
+	"webhook-server/service/model"
+	"webhook-server/service/templates"
+)
+
+const (
+	// Telegram rejects callback data longer than 64 bytes
+	telegramCallbackDataLimit = 64
+	// Suppress buttons carry the duration: mute:<duration>:<alert key>
+	telegramMutePrefix = "mute:"
+)
+
+// TelegramUpdatesHandler receives bot updates registered with setWebhook and
+// handles the suppress and acknowledge buttons on firing messages.
+func (rc *RestController) TelegramUpdatesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	config, err := config.GetConfig()
+	if err != nil {
+		log.Printf("Error loading config: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// Verify the secret token passed to setWebhook
+	secret := r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+	if config.TelegramWebhookSecret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(config.TelegramWebhookSecret)) != 1 {
+		http.Error(w, "Invalid secret token", http.StatusUnauthorized)
+		return
+	}
+
+	var update model.TelegramUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		log.Printf("Invalid update JSON: %v", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// Always acknowledge the update, otherwise Telegram keeps redelivering it
+	w.WriteHeader(http.StatusOK)
+
+	query := update.CallbackQuery
+	if query == nil {
+		return
+	}
+
+	username := query.From.Username
+	if username == "" {
+		username = query.From.FirstName
+	}
+
+	switch {
+	case strings.HasPrefix(query.Data, telegramMutePrefix):
+		value, _, _ := strings.Cut(strings.TrimPrefix(query.Data, telegramMutePrefix), ":")
+		duration, err := helper.ParseDuration(value)
+		if err != nil || duration <= 0 || duration > maxSuppressDuration {
+			log.Printf("Invalid suppress duration in %s: %v", query.Data, err)
+			rc.answerTelegram(query.ID, "Thời gian tắt thông báo không hợp lệ")
+			return
+		}
+		rc.suppressFromTelegram(query, telegramMutePrefix+value+":", duration, username)
+	case strings.HasPrefix(query.Data, suppressKeyPrefix):
+		// Buttons on messages sent before the duration choices existed
+		rc.suppressFromTelegram(query, suppressKeyPrefix, suppressDuration, username)
+	case strings.HasPrefix(query.Data, ackKeyPrefix):
+		rc.acknowledgeFromTelegram(query, username)
+	}
+}
+
+// suppressFromTelegram silences the alert of the button for the duration and
+// notes it on the message, keeping the message's formatting.
+func (rc *RestController) suppressFromTelegram(query *model.TelegramCallbackQuery, prefix string, duration time.Duration, username string) {
+	target, err := rc.resolveAlertKey(context.TODO(), prefix, query.Data)
+	if err != nil {
+		log.Printf("Error resolving alert for %s: %v", query.Data, err)
+		rc.answerTelegram(query.ID, "Không tìm thấy cảnh báo để tắt thông báo")
+		return
+	}
+
+	suppressedUntil := time.Now().Add(duration)
+	if err := rc.suppressTarget(context.TODO(), target, suppressedUntil, username, "User resolved via Telegram"); err != nil {
+		log.Printf("Error suppressing alert: %v", err)
+		rc.answerTelegram(query.ID, "Không thể tắt thông báo, vui lòng thử lại")
+		return
+	}
+
+	// Update original message, keeping the ack button until someone takes it
+	if query.Message != nil {
+		var keyboard *model.InlineKeyboardMarkup
+		alert := model.Alert{Fingerprint: target.Fingerprint}
+		if rc.alertOwner(context.TODO(), alert.Fingerprint) == "" {
+			if row := telegramAckRow(alert); row != nil {
+				keyboard = &model.InlineKeyboardMarkup{InlineKeyboard: [][]model.InlineKeyboardButton{row}}
+			}
+		}
+		updatedMessage := fmt.Sprintf("%s\n\n🔕 Thông báo %s sẽ được bỏ qua trong %s bởi %s",
+			telegramHTML(query.Message.Text, query.Message.Entities), target.describe(telegramBold),
+			suppressLabel(duration), html.EscapeString(username))
+		chatID := strconv.FormatInt(query.Message.Chat.ID, 10)
+		if err := rc.Telegram.EditTelegramMessage(chatID, query.Message.MessageID, updatedMessage, keyboard); err != nil {
+			log.Printf("Error updating message: %v", err)
+		}
+	}
+
+	rc.answerTelegram(query.ID, fmt.Sprintf("Thông báo %s sẽ được bỏ qua trong %s.", target.describe(plainText), suppressLabel(duration)))
+}
+
+// acknowledgeFromTelegram makes the user the owner of the alert and shows
+// them on the message, keeping the suppress button.
+func (rc *RestController) acknowledgeFromTelegram(query *model.TelegramCallbackQuery, username string) {
+	ctx := context.TODO()
+	fingerprint, err := ackFingerprint(query.Data)
+	var record *AlertRecord
+	if err == nil {
+		record, err = rc.findAlertRecord(ctx, fingerprint)
+	}
+	if err != nil || record == nil {
+		log.Printf("Error resolving alert for %s: %v", query.Data, err)
+		rc.answerTelegram(query.ID, "Không tìm thấy cảnh báo để nhận xử lý")
+		return
+	}
+	if record.Status == "resolved" {
+		rc.answerTelegram(query.ID, "Cảnh báo đã được giải quyết")
+		return
+	}
+
+	if _, err := rc.acknowledgeAlert(ctx, fingerprint, username); err != nil {
+		log.Printf("Error acknowledging alert: %v", err)
+		rc.answerTelegram(query.ID, "Không thể nhận xử lý cảnh báo, vui lòng thử lại")
+		return
+	}
+
+	if query.Message != nil {
+		alert := model.Alert{Fingerprint: record.Fingerprint, Labels: record.Labels, Status: record.Status}
+		var keyboard *model.InlineKeyboardMarkup
+		if row := telegramSuppressRow(alert); row != nil {
+			keyboard = &model.InlineKeyboardMarkup{InlineKeyboard: [][]model.InlineKeyboardButton{row}}
+		}
+		updatedMessage := fmt.Sprintf("%s\n\n👤 Đã nhận xử lý bởi %s",
+			telegramHTML(query.Message.Text, query.Message.Entities), html.EscapeString(username))
+		chatID := strconv.FormatInt(query.Message.Chat.ID, 10)
+		if err := rc.Telegram.EditTelegramMessage(chatID, query.Message.MessageID, updatedMessage, keyboard); err != nil {
+			log.Printf("Error updating message: %v", err)
+		}
+	}
+
+	rc.answerTelegram(query.ID, "Bạn đã nhận xử lý cảnh báo. Thông báo lặp lại sẽ được bỏ qua cho đến khi cảnh báo được giải quyết.")
+}
+
+func (rc *RestController) answerTelegram(callbackQueryID, text string) {
+	if err := rc.Telegram.AnswerCallbackQuery(callbackQueryID, text); err != nil {
+		log.Printf("Error answering callback query: %v", err)
+	}
+}
+
+// buildTelegramMessage returns the Telegram message for the rendered alert,
+// with the suppress and acknowledge buttons when firing.
+func buildTelegramMessage(alert model.Alert, message *templates.Message) model.TelegramMessage {
+	result := model.TelegramMessage{Text: message.Body, ParseMode: "HTML"}
+	if alert.Status == "firing" {
+		result.ReplyMarkup = buildTelegramKeyboard(alert)
+	}
+	return result
+}
+
+// buildTelegramKeyboard returns the suppress and acknowledge buttons for a
+// firing message. Buttons whose data would not fit in Telegram's callback data
+// are left out.
+func buildTelegramKeyboard(alert model.Alert) *model.InlineKeyboardMarkup {
+	var rows [][]model.InlineKeyboardButton
+	if row := telegramSuppressRow(alert); row != nil {
+		rows = append(rows, row)
+	}
+	if row := telegramAckRow(alert); row != nil {
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return &model.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// telegramSuppressRow returns a suppress button for each duration choice.
+func telegramSuppressRow(alert model.Alert) []model.InlineKeyboardButton {
+	var row []model.InlineKeyboardButton
+	for _, choice := range suppressChoices {
+		data := alertKey(telegramMutePrefix+choice.Value+":", alert)
+		if len(data) > telegramCallbackDataLimit {
+			log.Printf("Callback data too long for alert %s, sending without suppress buttons", alert.Fingerprint)
+			return nil
+		}
+		row = append(row, model.InlineKeyboardButton{Text: "🔕 " + choice.Label, CallbackData: data})
+	}
+	return row
+}
+
+func telegramAckRow(alert model.Alert) []model.InlineKeyboardButton {
+	if alert.Fingerprint == "" || len(ackKeyPrefix+alert.Fingerprint) > telegramCallbackDataLimit {
+		return nil
+	}
+	return []model.InlineKeyboardButton{
+		{Text: "Nhận xử lý", CallbackData: ackKeyPrefix + alert.Fingerprint},
+	}
+}
+
+// telegramEntityTags returns the HTML tags of a formatting entity, or empty
+// tags for entities Telegram detects by itself, such as links and mentions.
+func telegramEntityTags(entity model.TelegramMessageEntity) (string, string) {
+	switch entity.Type {
+	case "bold":
+		return "<b>", "</b>"
+	case "italic":
+		return "<i>", "</i>"
+	case "underline":
+		return "<u>", "</u>"
+	case "strikethrough":
+		return "<s>", "</s>"
+	case "spoiler":
+		return "<tg-spoiler>", "</tg-spoiler>"
+	case "code":
+		return "<code>", "</code>"
+	case "pre":
+		if entity.Language != "" {
+			return fmt.Sprintf(`<pre><code class="language-%s">`, html.EscapeString(entity.Language)), "</code></pre>"
+		}
+		return "<pre>", "</pre>"
+	case "text_link":
+		return fmt.Sprintf(`<a href="%s">`, html.EscapeString(entity.URL)), "</a>"
+	case "blockquote":
+		return "<blockquote>", "</blockquote>"
+	case "expandable_blockquote":
+		return "<blockquote expandable>", "</blockquote>"
+	}
+	return "", ""
+}
+
+// telegramHTML turns the text of a received message and its formatting
+// entities back into the HTML it was sent as, so an edit keeps the format.
+func telegramHTML(text string, entities []model.TelegramMessageEntity) string {
+	units := utf16.Encode([]rune(text))
+
+	// Outer entities first, so nested ones close before them
+	sorted := append([]model.TelegramMessageEntity(nil), entities...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Offset != sorted[j].Offset {
+			return sorted[i].Offset < sorted[j].Offset
+		}
+		return sorted[i].Length > sorted[j].Length
+	})
+
+	opens := map[int][]string{}
+	closes := map[int][]string{}
+	positions := []int{0, len(units)}
+	for _, entity := range sorted {
+		open, close := telegramEntityTags(entity)
+		end := entity.Offset + entity.Length
+		if open == "" || entity.Offset < 0 || entity.Length <= 0 || end > len(units) {
+			continue
+		}
+		opens[entity.Offset] = append(opens[entity.Offset], open)
+		closes[end] = append([]string{close}, closes[end]...)
+		positions = append(positions, entity.Offset, end)
+	}
+	sort.Ints(positions)
+
+	var b strings.Builder
+	last := 0
+	for _, pos := range positions {
+		if pos < last {
+			continue
+		}
+		b.WriteString(html.EscapeString(string(utf16.Decode(units[last:pos]))))
+		for _, tag := range closes[pos] {
+			b.WriteString(tag)
+		}
+		for _, tag := range opens[pos] {
+			b.WriteString(tag)
+		}
+		delete(closes, pos)
+		delete(opens, pos)
+		last = pos
+	}
+	return b.String()
+}
+
+func telegramBold(text string) string {
+	return "<b>" + html.EscapeString(text) + "</b>"
+}
+
+func plainText(text string) string {
+	return text
+}
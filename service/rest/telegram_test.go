@@ -0,0 +1,81 @@
+package rest
+
+import (
+	"testing"
+
+	"webhook-server/service/model"
+)
+
+func TestTelegramHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		entities []model.TelegramMessageEntity
+		want     string
+	}{
+		{
+			name: "plain text is escaped",
+			text: "a < b & c",
+			want: "a &lt; b &amp; c",
+		},
+		{
+			name:     "bold",
+			text:     "DiskFull on db-1",
+			entities: []model.TelegramMessageEntity{{Type: "bold", Offset: 0, Length: 8}},
+			want:     "<b>DiskFull</b> on db-1",
+		},
+		{
+			name: "nested",
+			text: "Ổ đĩa đầy",
+			entities: []model.TelegramMessageEntity{
+				{Type: "italic", Offset: 6, Length: 3},
+				{Type: "bold", Offset: 0, Length: 9},
+			},
+			want: "<b>Ổ đĩa <i>đầy</i></b>",
+		},
+		{
+			name:     "offsets count UTF-16 units",
+			text:     "🔥 CPU",
+			entities: []model.TelegramMessageEntity{{Type: "code", Offset: 3, Length: 3}},
+			want:     "🔥 <code>CPU</code>",
+		},
+		{
+			name: "link and pre",
+			text: "Dashboard\nx > 1",
+			entities: []model.TelegramMessageEntity{
+				{Type: "text_link", Offset: 0, Length: 9, URL: "https://grafana.example.com/d/a?b=1&c=2"},
+				{Type: "pre", Offset: 10, Length: 5, Language: "go"},
+			},
+			want: `<a href="https://grafana.example.com/d/a?b=1&amp;c=2">Dashboard</a>` + "\n" + `<pre><code class="language-go">x &gt; 1</code></pre>`,
+		},
+		{
+			name: "detected and invalid entities are skipped",
+			text: "see https://example.com",
+			entities: []model.TelegramMessageEntity{
+				{Type: "url", Offset: 4, Length: 19},
+				{Type: "bold", Offset: 20, Length: 10},
+			},
+			want: "see https://example.com",
+		},
+	}
+	for _, tt := range tests {
+		if got := telegramHTML(tt.text, tt.entities); got != tt.want {
+			t.Errorf("%s: telegramHTML() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTelegramSuppressRow(t *testing.T) {
+	row := telegramSuppressRow(model.Alert{Fingerprint: "a1b2c3d4e5f60718"})
+	if len(row) != len(suppressChoices) {
+		t.Fatalf("telegramSuppressRow() has %d buttons, want %d", len(row), len(suppressChoices))
+	}
+	if got := row[3].CallbackData; got != "mute:72h:a1b2c3d4e5f60718" {
+		t.Errorf("CallbackData = %q", got)
+	}
+
+	long := model.Alert{Labels: map[string]string{"instance": "very-long-host-name.datacenter.example.com:9100", "device": "/dev/mapper/data"}}
+	if row := telegramSuppressRow(long); row != nil {
+		t.Errorf("telegramSuppressRow() = %v, want nil when the key does not fit", row)
+	}
+}
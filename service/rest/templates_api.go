@@ -0,0 +1,114 @@
+package rest
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"webhook-server/service/model"
+	"webhook-server/service/route"
+	"webhook-server/service/templates"
+)
+
+// renderRequest is a dry run of the templates of a receiver, or of a channel
+// type when Receiver is empty. Template replaces the stored templates.
+type renderRequest struct {
+	Receiver string               `json:"receiver"`
+	Type     string               `json:"type"`
+	Template string               `json:"template"`
+	Payload  model.WebhookMessage `json:"payload"`
+}
+
+type renderResponse struct {
+	Receiver string         `json:"receiver"`
+	Type     string         `json:"type"`
+	Alerts   []renderResult `json:"alerts"`
+}
+
+// renderResult is what would be sent for one alert. Error holds the template
+// error, with its line number, when the alert could not be rendered.
+type renderResult struct {
+	Fingerprint string      `json:"fingerprint,omitempty"`
+	Status      string      `json:"status"`
+	Template    string      `json:"template,omitempty"`
+	Payload     interface{} `json:"payload,omitempty"`
+	Error       string      `json:"error,omitempty"`
+}
+
+// RenderTemplatesHandler renders a webhook payload with a receiver's
+// templates and returns the messages that would be sent, without sending
+// them.
+func (rc *RestController) RenderTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	var req renderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if len(req.Payload.Alerts) == 0 {
+		http.Error(w, "No alerts found in payload", http.StatusBadRequest)
+		return
+	}
+
+	receiverName, channel := req.Receiver, req.Type
+	if req.Receiver != "" {
+		receiver, ok := rc.Receivers[req.Receiver]
+		if !ok {
+			receiver, ok = rc.Channels[req.Receiver]
+		}
+		if !ok {
+			http.Error(w, "Unknown receiver", http.StatusNotFound)
+			return
+		}
+		channel = receiver.Type
+	}
+	switch channel {
+	case route.ReceiverTelegram, route.ReceiverDiscord, route.ReceiverSlack, route.ReceiverTeams, route.ReceiverEmail:
+	default:
+		http.Error(w, "Receiver or a valid type is required", http.StatusBadRequest)
+		return
+	}
+	if receiverName == "" {
+		receiverName = channel
+	}
+
+	inheritGroupStatus(&req.Payload)
+
+	response := renderResponse{Receiver: receiverName, Type: channel, Alerts: []renderResult{}}
+	for _, alert := range req.Payload.Alerts {
+		result := renderResult{Fingerprint: alert.Fingerprint, Status: alert.Status}
+
+		message, err := rc.Templates.Preview(receiverName, channel, req.Template, templates.Data{
+			Alert:    alert,
+			Receiver: receiverName,
+			Duration: firingDuration(alert, time.Time{}),
+		})
+		if err != nil {
+			log.Printf("Error rendering preview for %s: %v", receiverName, err)
+			result.Error = err.Error()
+		} else {
+			result.Template = message.Template
+			result.Payload = previewPayload(channel, alert, message)
+		}
+		response.Alerts = append(response.Alerts, result)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// previewPayload builds the message the channel's notifier would send.
+func previewPayload(channel string, alert model.Alert, message *templates.Message) interface{} {
+	switch channel {
+	case route.ReceiverTelegram:
+		return buildTelegramMessage(alert, message)
+	case route.ReceiverDiscord:
+		return buildDiscordMessage(alert, message)
+	case route.ReceiverSlack:
+		return model.SlackMessage{Text: alert.Annotations["summary"], Blocks: buildSlackBlocks(alert, message)}
+	case route.ReceiverTeams:
+		return buildTeamsCard(alert, message)
+	case route.ReceiverEmail:
+		return map[string]string{"subject": emailSubject(alert, message), "text": message.Body, "html": message.HTML}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package route
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"webhook-server/service/matcher"
+)
+
+const (
+	ReceiverTelegram = "telegram"
+	ReceiverDiscord  = "discord"
+	ReceiverSlack    = "slack"
+	ReceiverTeams    = "teams"
+	ReceiverEmail    = "email"
+)
+
+// Config is the routing file: the named receivers and the tree that picks
+// them for each alert.
+type Config struct {
+	Receivers []Receiver `json:"receivers"`
+	Route     *Route     `json:"route"`
+}
+
+// Receiver is a named notification target. Empty targets, retry policy and
+// concurrency fall back to the defaults from the environment.
+type Receiver struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	ChatID      string   `json:"chat_id,omitempty"`
+	ChannelID   string   `json:"channel_id,omitempty"`
+	WebhookURL  string   `json:"webhook_url,omitempty"`
+	To          []string `json:"to,omitempty"`
+	Retry       *Retry   `json:"retry,omitempty"`
+	Concurrency int      `json:"concurrency,omitempty"`
+}
+
+// Retry overrides the delivery retry policy from the environment for one
+// receiver. Durations accept Go syntax plus d and w.
+type Retry struct {
+	MaxAttempts    int    `json:"max_attempts,omitempty"`
+	InitialBackoff string `json:"initial_backoff,omitempty"`
+	MaxBackoff     string `json:"max_backoff,omitempty"`
+}
+
+// Route is a node in the routing tree. An alert matching a route is passed on
+// to its children; the first matching child wins unless it sets Continue. If
+// no child matches, the route's own receiver is used.
+type Route struct {
+	Receiver string   `json:"receiver,omitempty"`
+	Matchers []string `json:"matchers,omitempty"`
+	Continue bool     `json:"continue,omitempty"`
+	Routes   []*Route `json:"routes,omitempty"`
+
+	matchers matcher.Matchers
+}
+
+// Load reads and validates a routing file.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read routing config: %w", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse routing config: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Validate checks the receivers and compiles the routing tree.
+func (c *Config) Validate() error {
+	names := make(map[string]bool)
+	for _, receiver := range c.Receivers {
+		if receiver.Name == "" {
+			return fmt.Errorf("receiver without name")
+		}
+		if names[receiver.Name] {
+			return fmt.Errorf("duplicate receiver %q", receiver.Name)
+		}
+		if receiver.Type == "" {
+			return fmt.Errorf("receiver %q has no type", receiver.Name)
+		}
+		names[receiver.Name] = true
+	}
+
+	if c.Route == nil {
+		return fmt.Errorf("routing config has no route")
+	}
+	if c.Route.Receiver == "" && len(c.Route.Routes) == 0 {
+		return fmt.Errorf("root route needs a receiver or child routes")
+	}
+	if len(c.Route.Matchers) > 0 {
+		return fmt.Errorf("root route must not have matchers")
+	}
+	return c.Route.compile("", names)
+}
+
+func (r *Route) compile(parentReceiver string, receivers map[string]bool) error {
+	if r.Receiver == "" {
+		r.Receiver = parentReceiver
+	}
+	if r.Receiver != "" && !receivers[r.Receiver] {
+		return fmt.Errorf("route references unknown receiver %q", r.Receiver)
+	}
+
+	matchers, err := matcher.ParseAll(r.Matchers)
+	if err != nil {
+		return err
+	}
+	r.matchers = matchers
+
+	for _, child := range r.Routes {
+		if err := child.compile(r.Receiver, receivers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Match returns the deepest routes matching the labels. The root route always
+// matches.
+func (r *Route) Match(labels map[string]string) []*Route {
+	if !r.matchers.Matches(labels) {
+		return nil
+	}
+
+	var matches []*Route
+	for _, child := range r.Routes {
+		childMatches := child.Match(labels)
+		matches = append(matches, childMatches...)
+		if len(childMatches) > 0 && !child.Continue {
+			break
+		}
+	}
+
+	if len(matches) == 0 {
+		matches = append(matches, r)
+	}
+	return matches
+}
+
+// Receivers returns the distinct receiver names selected for the labels, in
+// routing order.
+func (r *Route) Receivers(labels map[string]string) []string {
+	seen := make(map[string]bool)
+	var receivers []string
+	for _, match := range r.Match(labels) {
+		if match.Receiver == "" || seen[match.Receiver] {
+			continue
+		}
+		seen[match.Receiver] = true
+		receivers = append(receivers, match.Receiver)
+	}
+	return receivers
+}
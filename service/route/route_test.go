@@ -0,0 +1,250 @@
+package route
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func receivers(names ...string) []Receiver {
+	result := make([]Receiver, len(names))
+	for i, name := range names {
+		result[i] = Receiver{Name: name, Type: ReceiverTelegram}
+	}
+	return result
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			config: Config{Receivers: receivers("ops"), Route: &Route{Receiver: "ops"}},
+		},
+		{
+			name: "children only",
+			config: Config{Receivers: receivers("db"), Route: &Route{Routes: []*Route{
+				{Receiver: "db", Matchers: []string{`team="db"`}},
+			}}},
+		},
+		{
+			name:    "receiver without name",
+			config:  Config{Receivers: []Receiver{{Type: ReceiverSlack}}, Route: &Route{Receiver: "ops"}},
+			wantErr: "receiver without name",
+		},
+		{
+			name:    "duplicate receiver",
+			config:  Config{Receivers: receivers("ops", "ops"), Route: &Route{Receiver: "ops"}},
+			wantErr: `duplicate receiver "ops"`,
+		},
+		{
+			name:    "receiver without type",
+			config:  Config{Receivers: []Receiver{{Name: "ops"}}, Route: &Route{Receiver: "ops"}},
+			wantErr: `receiver "ops" has no type`,
+		},
+		{
+			name:    "no route",
+			config:  Config{Receivers: receivers("ops")},
+			wantErr: "no route",
+		},
+		{
+			name:    "empty root",
+			config:  Config{Receivers: receivers("ops"), Route: &Route{}},
+			wantErr: "root route needs a receiver or child routes",
+		},
+		{
+			name:    "root with matchers",
+			config:  Config{Receivers: receivers("ops"), Route: &Route{Receiver: "ops", Matchers: []string{`team="db"`}}},
+			wantErr: "root route must not have matchers",
+		},
+		{
+			name:    "unknown root receiver",
+			config:  Config{Receivers: receivers("ops"), Route: &Route{Receiver: "missing"}},
+			wantErr: `unknown receiver "missing"`,
+		},
+		{
+			name: "unknown child receiver",
+			config: Config{Receivers: receivers("ops"), Route: &Route{Receiver: "ops", Routes: []*Route{
+				{Receiver: "ops", Routes: []*Route{{Receiver: "missing", Matchers: []string{`team="db"`}}}},
+			}}},
+			wantErr: `unknown receiver "missing"`,
+		},
+		{
+			name: "invalid matcher",
+			config: Config{Receivers: receivers("ops"), Route: &Route{Receiver: "ops", Routes: []*Route{
+				{Matchers: []string{`team=~"db-("`}},
+			}}},
+			wantErr: "invalid regular expression",
+		},
+		{
+			name: "invalid label name",
+			config: Config{Receivers: receivers("ops"), Route: &Route{Receiver: "ops", Routes: []*Route{
+				{Matchers: []string{`my-team="db"`}},
+			}}},
+			wantErr: "invalid label name",
+		},
+	}
+	for _, tt := range tests {
+		err := tt.config.Validate()
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: Validate() error = %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: Validate() error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestReceivers(t *testing.T) {
+	config := Config{
+		Receivers: receivers("default", "db", "db-critical", "web", "audit", "pager"),
+		Route: &Route{
+			Receiver: "default",
+			Routes: []*Route{
+				// Sees every alert and passes it on
+				{Receiver: "audit", Matchers: []string{`audit="true"`}, Continue: true},
+				{
+					Receiver: "db",
+					Matchers: []string{`team="db"`},
+					Routes: []*Route{
+						{Receiver: "db-critical", Matchers: []string{`severity="critical"`}},
+						// Inherits the db receiver
+						{Matchers: []string{`severity="warning"`, `instance=~"replica-.*"`}},
+					},
+				},
+				{Receiver: "web", Matchers: []string{`team=~"web|frontend"`}},
+				// Also matches alerts without a team label
+				{Receiver: "pager", Matchers: []string{`severity="critical"`, `team!="web"`}},
+				// Never reached by critical alerts, the route above does not continue
+				{Receiver: "audit", Matchers: []string{`severity="critical"`}},
+			},
+		},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   []string
+	}{
+		{
+			name:   "no child matches",
+			labels: map[string]string{"team": "ops"},
+			want:   []string{"default"},
+		},
+		{
+			name:   "deepest match",
+			labels: map[string]string{"team": "db", "severity": "critical"},
+			want:   []string{"db-critical"},
+		},
+		{
+			name:   "falls back to the parent receiver",
+			labels: map[string]string{"team": "db", "severity": "info"},
+			want:   []string{"db"},
+		},
+		{
+			name:   "inherited receiver",
+			labels: map[string]string{"team": "db", "severity": "warning", "instance": "replica-1"},
+			want:   []string{"db"},
+		},
+		{
+			name:   "regex is anchored",
+			labels: map[string]string{"team": "web-2"},
+			want:   []string{"default"},
+		},
+		{
+			name:   "regex alternative",
+			labels: map[string]string{"team": "frontend"},
+			want:   []string{"web"},
+		},
+		{
+			name:   "first match wins",
+			labels: map[string]string{"team": "web", "severity": "critical"},
+			want:   []string{"web"},
+		},
+		{
+			name:   "continue",
+			labels: map[string]string{"audit": "true", "team": "db", "severity": "critical"},
+			want:   []string{"audit", "db-critical"},
+		},
+		{
+			name:   "continue without other match",
+			labels: map[string]string{"audit": "true"},
+			want:   []string{"audit"},
+		},
+		{
+			name:   "missing label with not equal",
+			labels: map[string]string{"severity": "critical"},
+			want:   []string{"pager"},
+		},
+	}
+	for _, tt := range tests {
+		if got := config.Route.Receivers(tt.labels); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Receivers(%v) = %v, want %v", tt.name, tt.labels, got, tt.want)
+		}
+	}
+}
+
+func TestReceiversDistinct(t *testing.T) {
+	config := Config{
+		Receivers: receivers("ops"),
+		Route: &Route{Routes: []*Route{
+			{Receiver: "ops", Matchers: []string{`team="db"`}, Continue: true},
+			{Receiver: "ops", Matchers: []string{`severity="critical"`}},
+		}},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	if got := config.Route.Receivers(map[string]string{"team": "db", "severity": "critical"}); !reflect.DeepEqual(got, []string{"ops"}) {
+		t.Errorf("Receivers() = %v, want [ops]", got)
+	}
+	// A root without receiver selects nothing when no child matches
+	if got := config.Route.Receivers(map[string]string{"team": "web"}); len(got) != 0 {
+		t.Errorf("Receivers() = %v, want none", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	path := write("routing.json", `{
+		"receivers": [{"name": "ops", "type": "telegram"}, {"name": "db", "type": "discord"}],
+		"route": {"receiver": "ops", "routes": [{"receiver": "db", "matchers": ["team=\"db\""]}]}
+	}`)
+	config, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := config.Route.Receivers(map[string]string{"team": "db"}); !reflect.DeepEqual(got, []string{"db"}) {
+		t.Errorf("Receivers() = %v, want [db]", got)
+	}
+
+	if _, err := Load(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("Load() of a missing file returned no error")
+	}
+	if _, err := Load(write("invalid.json", `{"receivers": [`)); err == nil {
+		t.Errorf("Load() of invalid JSON returned no error")
+	}
+	if _, err := Load(write("unknown.json", `{"receivers": [], "route": {"receiver": "ops"}}`)); err == nil {
+		t.Errorf("Load() with an unknown receiver returned no error")
+	}
+}
@@ -3,9 +3,12 @@ package service
 import (
 	"context"
 	"log"
+	"time"
 	"webhook-server/service/config"
 	"webhook-server/service/contact"
+	"webhook-server/service/helper"
 	"webhook-server/service/rest"
+	"webhook-server/service/templates"
 
 	"github.com/bwmarrin/discordgo"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -22,13 +25,11 @@ func InitService(mongoClient *mongo.Client) *rest.RestController {
 	if err != nil {
 		log.Fatalf("Error connecting to MongoDB: %v", err)
 	}
-	defer mongoClient.Disconnect(context.TODO())
 
 	discord, err := discordgo.New("Bot " + config.DiscordBotToken)
 	if err != nil {
 		log.Fatalf("Error creating Discord session: %v", err)
 	}
-	defer discord.Close()
 
 	discord.AddHandler(func(s *discordgo.Session, r *discordgo.Ready) {
 		log.Println("Discord bot is ready")
@@ -36,6 +37,24 @@ func InitService(mongoClient *mongo.Client) *rest.RestController {
 	if err := discord.Open(); err != nil {
 		log.Fatalf("Error opening Discord connection: %v", err)
 	}
+	if config.DiscordApplicationID != "" {
+		if err := rest.RegisterDiscordCommands(discord, config.DiscordApplicationID, config.DiscordGuildID); err != nil {
+			log.Printf("Error registering Discord commands: %v", err)
+		}
+	}
+
+	location, err := time.LoadLocation(config.TemplateTimezone)
+	if err != nil {
+		log.Fatalf("Error loading TEMPLATE_TIMEZONE: %v", err)
+	}
+
+	var valueRules []*templates.ValueRule
+	if config.ValueFormats != "" {
+		valueRules, err = templates.LoadValueRules(config.ValueFormats)
+		if err != nil {
+			log.Fatalf("Error loading value formats: %v", err)
+		}
+	}
 
 	server := &rest.RestController{
 		Telegram: &contact.TelegramSender{},
@@ -43,8 +62,45 @@ func InitService(mongoClient *mongo.Client) *rest.RestController {
 			Discord:   discord,
 			ChannelID: config.DiscordChannelID,
 		},
-		MongoClient: mongoClient,
+		Templates:      templates.NewStore(config.TemplatesDir, location, valueRules),
+		MongoClient:    mongoClient,
+		DiscordSession: discord,
+	}
+
+	if config.SlackBotToken != "" {
+		server.Slack = &contact.SlackSender{
+			BotToken:  config.SlackBotToken,
+			ChannelID: config.SlackChannelID,
+		}
+	}
+
+	routing, err := loadRouting(config)
+	if err != nil {
+		log.Fatalf("Error loading routing config: %v", err)
+	}
+	server.Router = routing.Route
+	server.Receivers, err = buildReceivers(config, routing, server, discord)
+	if err != nil {
+		log.Fatalf("Error creating receivers: %v", err)
+	}
+	server.Channels, err = buildChannels(config, server, discord)
+	if err != nil {
+		log.Fatalf("Error creating channels: %v", err)
+	}
+
+	retention, err := helper.ParseDuration(config.HistoryRetention)
+	if err != nil || retention <= 0 {
+		log.Fatalf("Invalid HISTORY_RETENTION %q", config.HistoryRetention)
+	}
+	if err := server.EnsureIndexes(context.TODO(), retention); err != nil {
+		log.Printf("Error creating MongoDB indexes: %v", err)
+	}
+
+	settings, err := deliverySettings(config)
+	if err != nil {
+		log.Fatalf("Error loading delivery settings: %v", err)
 	}
+	server.StartDeliveryWorkers(settings)
 
 	return server
 }
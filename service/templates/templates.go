@@ -0,0 +1,283 @@
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+
+	"webhook-server/service/helper"
+	"webhook-server/service/model"
+	"webhook-server/service/route"
+)
+
+//go:embed defaults
+var defaults embed.FS
+
+// Channels whose message body is HTML and gets escaped accordingly
+var htmlChannels = map[string]bool{
+	route.ReceiverTelegram: true,
+}
+
+// Data is what a template is executed with. The alert's fields are available
+// directly, e.g. {{ .Labels.instance }}.
+type Data struct {
+	model.Alert
+	Receiver string
+	// Duration is how long a resolved alert fired, zero when unknown.
+	Duration time.Duration
+	// DisplayValues are the alert's values formatted for display, filled in
+	// by the store.
+	DisplayValues []Value
+}
+
+// Message is a rendered template. Body is the template itself; Title and
+// HTML come from the optional "title" and "html" templates it defines. Title
+// is the Slack and Teams heading or the email subject. Template is the file
+// the message was rendered from.
+type Message struct {
+	Template string
+	Title    string
+	Body     string
+	HTML     string
+}
+
+// Store loads templates from a directory, falling back to the built-in
+// defaults. For a receiver, status and alertname it uses the first of
+//
+//	<receiver>/<alertname>/<status>.tmpl
+//	<receiver>/<status>.tmpl
+//	<type>/<alertname>/<status>.tmpl
+//	<type>/<status>.tmpl
+//
+// Files are parsed once and parsed again when their modification time
+// changes. A file that fails to parse keeps its last working version.
+type Store struct {
+	dir        string
+	funcs      map[string]interface{}
+	valueRules []*ValueRule
+
+	mu    sync.Mutex
+	cache map[string]*entry
+}
+
+type entry struct {
+	modTime time.Time
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+	// err is why the current version of the file did not load
+	err error
+}
+
+// NewStore creates a store for the templates in dir, formatting times in loc
+// and values with the first matching rule. An empty dir uses only the
+// built-in templates.
+func NewStore(dir string, loc *time.Location, valueRules []*ValueRule) *Store {
+	return &Store{
+		dir:        dir,
+		funcs:      helper.TemplateFuncs(loc),
+		valueRules: valueRules,
+		cache:      make(map[string]*entry),
+	}
+}
+
+// Render renders the template for an alert sent to the receiver of the given
+// channel type.
+func (s *Store) Render(receiver, channel string, data Data) (*Message, error) {
+	e, name, err := s.lookup(receiver, channel, data.Alert, false)
+	if err != nil {
+		return nil, err
+	}
+	return s.execute(e, name, channel, data)
+}
+
+// Preview renders like Render without falling back to the last working
+// version of a file that no longer parses. A non-empty source is rendered
+// instead of the stored templates.
+func (s *Store) Preview(receiver, channel, source string, data Data) (*Message, error) {
+	if source != "" {
+		e, err := s.parse("preview", source)
+		if err != nil {
+			return nil, err
+		}
+		return s.execute(e, "preview", channel, data)
+	}
+
+	e, name, err := s.lookup(receiver, channel, data.Alert, true)
+	if err != nil {
+		return nil, err
+	}
+	return s.execute(e, name, channel, data)
+}
+
+func (s *Store) execute(e *entry, name, channel string, data Data) (*Message, error) {
+	data.DisplayValues = s.displayValues(data.Alert)
+
+	message := Message{Template: name}
+	var err error
+	if htmlChannels[channel] {
+		message.Body, err = executeHTML(e.html, name, data)
+	} else {
+		message.Body, err = executeText(e.text, name, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	message.Body = strings.TrimSpace(message.Body)
+
+	if e.text.Lookup("title") != nil {
+		if message.Title, err = executeText(e.text, "title", data); err != nil {
+			return nil, err
+		}
+		message.Title = strings.TrimSpace(message.Title)
+	}
+	if e.html.Lookup("html") != nil {
+		if message.HTML, err = executeHTML(e.html, "html", data); err != nil {
+			return nil, err
+		}
+	}
+	return &message, nil
+}
+
+// lookup returns the template for the alert and the path it was loaded from.
+// Unless strict, a file that no longer parses is replaced by its last working
+// version, or skipped if it never parsed.
+func (s *Store) lookup(receiver, channel string, alert model.Alert, strict bool) (*entry, string, error) {
+	status := alert.Status + ".tmpl"
+	alertname := alert.Labels["alertname"]
+
+	if s.dir != "" {
+		var candidates []string
+		for _, dir := range []string{receiver, channel} {
+			if alertname != "" && !strings.Contains(alertname, "/") {
+				candidates = append(candidates, path.Join(dir, alertname, status))
+			}
+			candidates = append(candidates, path.Join(dir, status))
+		}
+
+		for _, name := range candidates {
+			if !fs.ValidPath(name) {
+				continue
+			}
+			e := s.loadFile(name)
+			if e == nil {
+				continue
+			}
+			if strict && e.err != nil {
+				return nil, name, e.err
+			}
+			if e.text != nil {
+				return e, name, nil
+			}
+		}
+	}
+
+	name := path.Join("defaults", channel, status)
+	e, err := s.loadDefault(name)
+	if err != nil {
+		return nil, "", err
+	}
+	return e, name, nil
+}
+
+// loadFile returns the template file, or nil if it does not exist. If the
+// current version does not parse, the entry keeps the last working one.
+func (s *Store) loadFile(name string) *entry {
+	filename := filepath.Join(s.dir, filepath.FromSlash(name))
+	info, err := os.Stat(filename)
+	if err != nil || info.IsDir() {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cached := s.cache[name]
+	if cached != nil && cached.modTime.Equal(info.ModTime()) {
+		return cached
+	}
+
+	e, err := s.parseFile(name, filename)
+	if err != nil {
+		log.Printf("Error loading template %s: %v", filename, err)
+		// Don't retry until the file changes again
+		e = &entry{err: err}
+		if cached != nil {
+			e.text, e.html = cached.text, cached.html
+		}
+	} else {
+		log.Printf("Loaded template %s", filename)
+	}
+	e.modTime = info.ModTime()
+	s.cache[name] = e
+	return e
+}
+
+func (s *Store) parseFile(name, filename string) (*entry, error) {
+	source, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+	return s.parse(name, string(source))
+}
+
+func (s *Store) loadDefault(name string) (*entry, error) {
+	key := "embedded:" + name
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e := s.cache[key]; e != nil {
+		return e, nil
+	}
+
+	source, err := defaults.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("no template for %s", strings.TrimPrefix(name, "defaults/"))
+	}
+	e, err := s.parse(name, string(source))
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache[key] = e
+	return e, nil
+}
+
+// parse parses the source for both plain text and HTML output.
+func (s *Store) parse(name, source string) (*entry, error) {
+	text, err := texttemplate.New(name).Option("missingkey=zero").Funcs(s.funcs).Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	html, err := htmltemplate.New(name).Option("missingkey=zero").Funcs(s.funcs).Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return &entry{text: text, html: html}, nil
+}
+
+func executeText(tmpl *texttemplate.Template, name string, data Data) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+func executeHTML(tmpl *htmltemplate.Template, name string, data Data) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,224 @@
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"webhook-server/service/helper"
+	"webhook-server/service/matcher"
+	"webhook-server/service/model"
+)
+
+// ValueFormat describes how to show one value of an alert. Value is
+// multiplied by Scale ("0.001" or "1/31536000") and then formatted by Unit:
+// "bytes", "percent" (a ratio), "si", "duration" (seconds) or any other text
+// appended after the number, which is rounded to Precision decimals.
+type ValueFormat struct {
+	Ref       string `json:"ref"`
+	Label     string `json:"label,omitempty"`
+	Unit      string `json:"unit,omitempty"`
+	Scale     string `json:"scale,omitempty"`
+	Precision *int   `json:"precision,omitempty"`
+}
+
+// ValueRule picks the values to show for alerts matching all its matchers.
+type ValueRule struct {
+	Matchers []string      `json:"matchers"`
+	Values   []ValueFormat `json:"values"`
+
+	matchers matcher.Matchers
+}
+
+// Value is a formatted alert value, available to templates as
+// .DisplayValues.
+type Value struct {
+	Ref   string
+	Label string
+	Text  string
+	Raw   float64
+}
+
+// LoadValueRules reads a file of value rules, {"rules": [...]}.
+func LoadValueRules(path string) ([]*ValueRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read value formats: %w", err)
+	}
+
+	var file struct {
+		Rules []*ValueRule `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse value formats: %w", err)
+	}
+
+	for i, rule := range file.Rules {
+		if rule.matchers, err = matcher.ParseAll(rule.Matchers); err != nil {
+			return nil, fmt.Errorf("value rule %d: %w", i, err)
+		}
+		for _, format := range rule.Values {
+			if format.Ref == "" {
+				return nil, fmt.Errorf("value rule %d: value without ref", i)
+			}
+			if _, err := parseScale(format.Scale); err != nil {
+				return nil, fmt.Errorf("value rule %d: %w", i, err)
+			}
+		}
+	}
+	return file.Rules, nil
+}
+
+// displayValues formats the alert's values. The "values" annotation lists the
+// refIDs to show, each with optional value_<ref>_label, _unit, _scale and
+// _precision annotations. Without it, the first matching rule is used, and
+// without one every value is shown as is, or the ValueString when there are
+// none.
+func (s *Store) displayValues(alert model.Alert) []Value {
+	formats := annotationFormats(alert.Annotations)
+	if formats == nil {
+		for _, rule := range s.valueRules {
+			if rule.matchers.Matches(alert.Labels) {
+				formats = rule.Values
+				break
+			}
+		}
+	}
+
+	if formats == nil {
+		if len(alert.Values) == 0 && alert.ValueString != "" {
+			return []Value{{Label: "Value", Text: alert.ValueString}}
+		}
+
+		refs := make([]string, 0, len(alert.Values))
+		for ref := range alert.Values {
+			refs = append(refs, ref)
+		}
+		sort.Strings(refs)
+		for _, ref := range refs {
+			formats = append(formats, ValueFormat{Ref: ref})
+		}
+	}
+
+	values := []Value{}
+	for _, format := range formats {
+		raw, ok := alert.Values[format.Ref]
+		if !ok {
+			continue
+		}
+		label := format.Label
+		if label == "" {
+			label = format.Ref
+		}
+		values = append(values, Value{
+			Ref:   format.Ref,
+			Label: label,
+			Text:  formatValue(raw, format),
+			Raw:   raw,
+		})
+	}
+	return values
+}
+
+func annotationFormats(annotations map[string]string) []ValueFormat {
+	refs := annotations["values"]
+	if refs == "" {
+		return nil
+	}
+
+	formats := []ValueFormat{}
+	for _, ref := range strings.Split(refs, ",") {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			continue
+		}
+
+		prefix := "value_" + ref + "_"
+		format := ValueFormat{
+			Ref:   ref,
+			Label: annotations[prefix+"label"],
+			Unit:  annotations[prefix+"unit"],
+			Scale: annotations[prefix+"scale"],
+		}
+		if value := annotations[prefix+"precision"]; value != "" {
+			precision, err := strconv.Atoi(value)
+			if err != nil || precision < 0 {
+				log.Printf("Ignoring invalid %sprecision annotation %q", prefix, value)
+			} else {
+				format.Precision = &precision
+			}
+		}
+		formats = append(formats, format)
+	}
+	return formats
+}
+
+func formatValue(value float64, format ValueFormat) string {
+	scale, err := parseScale(format.Scale)
+	if err != nil {
+		log.Printf("Ignoring %v", err)
+		scale = 1
+	}
+	value *= scale
+
+	var text string
+	switch format.Unit {
+	case "bytes":
+		text, err = helper.HumanizeBytes(value)
+	case "percent":
+		text, err = helper.HumanizePercentage(value)
+	case "si":
+		text, err = helper.HumanizeSI(value)
+	case "duration":
+		text = helper.HumanizeDuration(time.Duration(value * float64(time.Second)))
+	default:
+		text = formatNumber(value, format.Precision)
+		if format.Unit != "" {
+			text += " " + format.Unit
+		}
+	}
+	if err != nil {
+		return formatNumber(value, format.Precision)
+	}
+	return text
+}
+
+// formatNumber rounds to the precision, or to at most two decimals when it is
+// not set.
+func formatNumber(value float64, precision *int) string {
+	if precision != nil {
+		return strconv.FormatFloat(value, 'f', *precision, 64)
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+}
+
+// parseScale parses a factor such as "1000", "0.001" or "1/31536000". Empty
+// means 1.
+func parseScale(scale string) (float64, error) {
+	if scale == "" {
+		return 1, nil
+	}
+
+	numerator, denominator, isFraction := strings.Cut(scale, "/")
+	n, err := strconv.ParseFloat(strings.TrimSpace(numerator), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid scale %q", scale)
+	}
+	if !isFraction {
+		return n, nil
+	}
+	d, err := strconv.ParseFloat(strings.TrimSpace(denominator), 64)
+	if err != nil || d == 0 {
+		return 0, fmt.Errorf("invalid scale %q", scale)
+	}
+	return n / d, nil
+}